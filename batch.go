@@ -0,0 +1,222 @@
+package fileloaders
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchLoader is implemented by loaders that can fetch several files in fewer backend calls.
+// A *BatchError reports the paths that failed; any other error applies to every path.
+type BatchLoader interface {
+	LoadBatch(ctx context.Context, paths []string, opt ...LoaderOption) (map[string]*File, error)
+}
+
+// BatchSizer is implemented by BatchLoaders whose backend accepts a limited number of paths per call.
+// LoadAll splits their batches accordingly and loads the parts concurrently.
+type BatchSizer interface {
+	BatchSize() int
+}
+
+// wrapper is implemented by the loaders that wrap another one, such as CachingLoader and RetryLoader.
+type wrapper interface {
+	unwrap() Loader
+}
+
+// batchLoader returns the BatchLoader that loader wraps, looking through the wrappers.
+func batchLoader(loader Loader) (BatchLoader, bool) {
+	for {
+		w, ok := loader.(wrapper)
+		if !ok {
+			break
+		}
+		loader = w.unwrap()
+	}
+	v, ok := loader.(BatchLoader)
+	return v, ok
+}
+
+// loadBatch loads paths with loader when it, or the loader it wraps, is a BatchLoader.
+func loadBatch(ctx context.Context, loader Loader, paths []string, opt ...LoaderOption) (map[string]*File, error) {
+	if _, ok := batchLoader(loader); !ok {
+		return nil, ErrNotSupported
+	}
+	return loader.(BatchLoader).LoadBatch(ctx, paths, opt...)
+}
+
+// BatchError collects the errors of LoadAll by path.
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	paths := make([]string, 0, len(e.Errors))
+	for k := range e.Errors {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+	messages := make([]string, len(paths))
+	for i, v := range paths {
+		messages[i] = e.Errors[v].Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e *BatchError) Unwrap() []error {
+	result := make([]error, 0, len(e.Errors))
+	for _, v := range e.Errors {
+		result = append(result, v)
+	}
+	return result
+}
+
+type BatchOption func(o *batchOptions)
+
+type batchOptions struct {
+	concurrency   int
+	failFast      bool
+	loaderOptions []LoaderOption
+}
+
+// WithConcurrency limits how many loads run at the same time. The default is 8.
+func WithConcurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = n
+	}
+}
+
+// WithFailFast stops LoadAll at the first error instead of loading every path.
+func WithFailFast(failFast bool) BatchOption {
+	return func(o *batchOptions) {
+		o.failFast = failFast
+	}
+}
+
+// WithBatchLoaderOptions passes LoaderOptions to every load.
+func WithBatchLoaderOptions(opt ...LoaderOption) BatchOption {
+	return func(o *batchOptions) {
+		o.loaderOptions = append(o.loaderOptions, opt...)
+	}
+}
+
+// LoadAll loads every path concurrently and returns the files by path. Duplicate paths are loaded once.
+// When some paths fail, the files that were loaded are returned together with a *BatchError.
+func LoadAll(ctx context.Context, paths []string, opts ...BatchOption) (map[string]*File, error) {
+	return loadAll(ctx, FromContext(ctx), paths, opts...)
+}
+
+func (m *MapLoader) LoadAll(ctx context.Context, paths []string, opts ...BatchOption) (map[string]*File, error) {
+	return loadAll(ctx, m, paths, opts...)
+}
+
+func loadAll(ctx context.Context, m *MapLoader, paths []string, opts ...BatchOption) (map[string]*File, error) {
+	o := &batchOptions{concurrency: 8}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var tasks []func() (map[string]*File, error)
+	batches := make(map[Loader][]string)
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if m != nil {
+			if loader, _, ok := m.lookup(path); ok {
+				if _, ok = batchLoader(loader); ok {
+					batches[loader] = append(batches[loader], path)
+					continue
+				}
+			}
+		}
+		path := path
+		tasks = append(tasks, func() (map[string]*File, error) {
+			file, err := loadOrFile(ctx, m, path, o.loaderOptions...)
+			if err != nil {
+				return nil, &BatchError{Errors: map[string]error{path: err}}
+			}
+			return map[string]*File{path: file}, nil
+		})
+	}
+	for loader, paths := range batches {
+		size := len(paths)
+		if v, _ := batchLoader(loader); v != nil {
+			if sizer, ok := v.(BatchSizer); ok && sizer.BatchSize() > 0 {
+				size = sizer.BatchSize()
+			}
+		}
+		for start := 0; start < len(paths); start += size {
+			loader, batch := loader, paths[start:min(start+size, len(paths))]
+			tasks = append(tasks, func() (map[string]*File, error) {
+				files, err := loadBatch(ctx, loader, batch, o.loaderOptions...)
+				var batchErr *BatchError
+				if err != nil && !errors.As(err, &batchErr) {
+					batchErr = &BatchError{Errors: make(map[string]error, len(batch))}
+					for _, v := range batch {
+						if _, ok := files[v]; !ok {
+							batchErr.Errors[v] = err
+						}
+					}
+				}
+				if batchErr == nil {
+					return files, nil
+				}
+				return files, batchErr
+			})
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	result := make(map[string]*File, len(seen))
+	errs := make(map[string]error)
+	sem := make(chan struct{}, o.concurrency)
+	for _, task := range tasks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(task func() (map[string]*File, error)) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			files, err := task()
+			mu.Lock()
+			defer mu.Unlock()
+			for k, v := range files {
+				result[k] = v
+			}
+			var batchErr *BatchError
+			if errors.As(err, &batchErr) {
+				for k, v := range batchErr.Errors {
+					errs[k] = v
+				}
+				if o.failFast {
+					cancel()
+				}
+			}
+		}(task)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return result, &BatchError{Errors: errs}
+	}
+	if err := ctx.Err(); err != nil && len(result) < len(seen) {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,350 @@
+package fileloaders
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+type CacheEntry struct {
+	File      *File
+	FetchedAt time.Time
+	ExpiresAt time.Time
+}
+
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+type CacheOption func(c *CachingLoader)
+
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *CachingLoader) {
+		c.ttl = ttl
+	}
+}
+
+// WithMaxEntries limits the number of files kept by the default in-memory store.
+func WithMaxEntries(n int) CacheOption {
+	return func(c *CachingLoader) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes limits the total body size kept by the default in-memory store.
+func WithMaxBytes(n int64) CacheOption {
+	return func(c *CachingLoader) {
+		c.maxBytes = n
+	}
+}
+
+// WithStaleIfError serves the cached copy, marked with File.Stale, when reloading an entry fails.
+// maxStale limits how long after expiry an entry may still be used; zero means no limit.
+func WithStaleIfError(maxStale time.Duration) CacheOption {
+	return func(c *CachingLoader) {
+		c.staleIfError = true
+		c.maxStale = maxStale
+	}
+}
+
+func WithCacheStore(store CacheStore) CacheOption {
+	return func(c *CachingLoader) {
+		c.store = store
+	}
+}
+
+// WithCache wraps every loader registered before it with a CachingLoader, except the ones that already are.
+func WithCache(opts ...CacheOption) Option {
+	return func(m map[string]Loader) {
+		for k, v := range m {
+			if _, ok := v.(*CachingLoader); ok {
+				continue
+			}
+			m[k] = NewCachingLoader(v, opts...)
+		}
+	}
+}
+
+// CachingLoader keeps loaded files for the TTL. Once an entry expires it is revalidated
+// with IfChanged, so that backends answering ErrNotModified only refresh the TTL.
+type CachingLoader struct {
+	loader       Loader
+	store        CacheStore
+	ttl          time.Duration
+	maxEntries   int
+	maxBytes     int64
+	staleIfError bool
+	maxStale     time.Duration
+	now          func() time.Time
+}
+
+func NewCachingLoader(loader Loader, opts ...CacheOption) *CachingLoader {
+	c := &CachingLoader{
+		loader: loader,
+		ttl:    time.Minute,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.store == nil {
+		c.store = NewMemoryStore(c.maxEntries, c.maxBytes)
+	}
+	return c
+}
+
+// cacheSuffix returns what is appended to the path to form the cache key of opt. Ranged reads and
+// loader specific options, such as credentials, are not cached, as DedupLoader does not share them either.
+func cacheSuffix(opt []LoaderOption) (string, bool) {
+	o := NewOptions(opt...)
+	if !o.Comparable() || o.Range != nil {
+		return "", false
+	}
+	if v := o.Key(); v != "" {
+		return "\x00" + v, true
+	}
+	return "", true
+}
+
+// Load bypasses the cache for ranged reads and loader specific options, as Open does.
+func (c *CachingLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+	suffix, cacheable := cacheSuffix(opt)
+	if !cacheable {
+		return c.loader.Load(ctx, path, opt...)
+	}
+	key := path + suffix
+	entry, ok := c.store.Get(key)
+	now := c.now()
+	if ok && now.Before(entry.ExpiresAt) {
+		return entry.File.clone(), nil
+	}
+	if ok {
+		opt = append(opt[:len(opt):len(opt)], IfChanged(entry.File))
+	}
+	file, err := c.loader.Load(ctx, path, opt...)
+	if err != nil {
+		if ok && errors.Is(err, ErrNotModified) {
+			c.store.Set(key, &CacheEntry{
+				File:      entry.File,
+				FetchedAt: now,
+				ExpiresAt: now.Add(c.ttl),
+			})
+			return entry.File.clone(), nil
+		}
+		if ok && c.serveStale(entry, now, err) {
+			return entry.File.clone().Add(WithStale(err)), nil
+		}
+		return nil, err
+	}
+	c.store.Set(key, &CacheEntry{
+		File:      file.clone(),
+		FetchedAt: now,
+		ExpiresAt: now.Add(c.ttl),
+	})
+	return file, nil
+}
+
+func (c *CachingLoader) Open(ctx context.Context, path string, opt ...LoaderOption) (io.ReadCloser, *File, error) {
+	suffix, cacheable := cacheSuffix(opt)
+	if !cacheable {
+		return open(ctx, c.loader, path, opt...)
+	}
+	key := path + suffix
+	entry, ok := c.store.Get(key)
+	now := c.now()
+	if ok && now.Before(entry.ExpiresAt) {
+		file := entry.File.clone()
+		return io.NopCloser(bytes.NewReader(file.body)), file, nil
+	}
+	r, file, err := open(ctx, c.loader, path, opt...)
+	if err != nil && ok && c.serveStale(entry, now, err) {
+		file = entry.File.clone().Add(WithStale(err))
+		return io.NopCloser(bytes.NewReader(file.body)), file, nil
+	}
+	return r, file, err
+}
+
+func (c *CachingLoader) serveStale(entry *CacheEntry, now time.Time, err error) bool {
+	if !c.staleIfError || errors.Is(err, ErrNotExist) || errors.Is(err, ErrNotSupported) {
+		return false
+	}
+	return c.maxStale <= 0 || now.Before(entry.ExpiresAt.Add(c.maxStale))
+}
+
+func (c *CachingLoader) Save(ctx context.Context, path string, body io.Reader, opt ...LoaderOption) (*File, error) {
+	c.store.Delete(path)
+	return save(ctx, c.loader, path, body, opt...)
+}
+
+func (c *CachingLoader) Delete(ctx context.Context, path string, opt ...LoaderOption) error {
+	c.store.Delete(path)
+	return remove(ctx, c.loader, path, opt...)
+}
+
+func (c *CachingLoader) Stat(ctx context.Context, path string, opt ...LoaderOption) (*FileInfo, error) {
+	return stat(ctx, c.loader, path, opt...)
+}
+
+func (c *CachingLoader) unwrap() Loader {
+	return c.loader
+}
+
+// LoadBatch serves the fresh entries from the cache and loads the others in a single batch.
+// Expired entries are loaded again, since a batch cannot be revalidated path by path.
+func (c *CachingLoader) LoadBatch(ctx context.Context, paths []string, opt ...LoaderOption) (map[string]*File, error) {
+	suffix, cacheable := cacheSuffix(opt)
+	if !cacheable {
+		return loadBatch(ctx, c.loader, paths, opt...)
+	}
+	now := c.now()
+	result := make(map[string]*File, len(paths))
+	expired := make(map[string]*CacheEntry)
+	var misses []string
+	for _, path := range paths {
+		entry, ok := c.store.Get(path + suffix)
+		if ok && now.Before(entry.ExpiresAt) {
+			result[path] = entry.File.clone()
+			continue
+		}
+		if ok {
+			expired[path] = entry
+		}
+		misses = append(misses, path)
+	}
+	if len(misses) == 0 {
+		return result, nil
+	}
+	files, err := loadBatch(ctx, c.loader, misses, opt...)
+	for k, v := range files {
+		c.store.Set(k+suffix, &CacheEntry{
+			File:      v.clone(),
+			FetchedAt: now,
+			ExpiresAt: now.Add(c.ttl),
+		})
+		result[k] = v
+	}
+	if err == nil {
+		return result, nil
+	}
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		batchErr = &BatchError{Errors: make(map[string]error, len(misses))}
+		for _, v := range misses {
+			if _, ok := files[v]; !ok {
+				batchErr.Errors[v] = err
+			}
+		}
+	}
+	errs := make(map[string]error)
+	for k, v := range batchErr.Errors {
+		if entry, ok := expired[k]; ok && c.serveStale(entry, now, v) {
+			result[k] = entry.File.clone().Add(WithStale(v))
+			continue
+		}
+		errs[k] = v
+	}
+	if len(errs) > 0 {
+		return result, &BatchError{Errors: errs}
+	}
+	return result, nil
+}
+
+func (c *CachingLoader) List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
+	return c.loader.List(ctx, path, opt...)
+}
+
+func (c *CachingLoader) ListEntries(ctx context.Context, path string, opt ...LoaderOption) ([]Entry, error) {
+	return listEntries(ctx, c.loader, path, opt...)
+}
+
+func (c *CachingLoader) Walk(ctx context.Context, path string, fn WalkFunc, opt ...LoaderOption) error {
+	return walk(ctx, c.loader, path, fn, opt...)
+}
+
+func (c *CachingLoader) Watch(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error) {
+	return watch(ctx, c.loader, path, opt...)
+}
+
+// Invalidate drops the cached copy of path.
+func (c *CachingLoader) Invalidate(path string) {
+	c.store.Delete(path)
+}
+
+// MemoryStore is an in-process LRU CacheStore. Zero limits mean unlimited.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	size       int64
+	lru        *list.List
+	items      map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+func NewMemoryStore(maxEntries int, maxBytes int64) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryStore) Get(key string) (*CacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.lru.MoveToFront(e)
+	return e.Value.(*memoryItem).entry, true
+}
+
+func (s *MemoryStore) Set(key string, entry *CacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	size := int64(len(entry.File.body))
+	if s.maxBytes > 0 && size > s.maxBytes {
+		s.remove(key)
+		return
+	}
+	if e, ok := s.items[key]; ok {
+		item := e.Value.(*memoryItem)
+		s.size += size - int64(len(item.entry.File.body))
+		item.entry = entry
+		s.lru.MoveToFront(e)
+	} else {
+		s.items[key] = s.lru.PushFront(&memoryItem{key: key, entry: entry})
+		s.size += size
+	}
+	for (s.maxEntries > 0 && s.lru.Len() > s.maxEntries) || (s.maxBytes > 0 && s.size > s.maxBytes) {
+		s.remove(s.lru.Back().Value.(*memoryItem).key)
+	}
+}
+
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(key)
+}
+
+func (s *MemoryStore) remove(key string) {
+	e, ok := s.items[key]
+	if !ok {
+		return
+	}
+	s.lru.Remove(e)
+	delete(s.items, key)
+	s.size -= int64(len(e.Value.(*memoryItem).entry.File.body))
+}
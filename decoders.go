@@ -0,0 +1,63 @@
+package fileloaders
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Decoder unmarshals the body of a File into v.
+type Decoder func(data []byte, v any) error
+
+var (
+	decoderMu sync.RWMutex
+	decoders  = map[string]Decoder{
+		".json":            json.Unmarshal,
+		"application/json": json.Unmarshal,
+		".xml":             xml.Unmarshal,
+		"application/xml":  xml.Unmarshal,
+		"text/xml":         xml.Unmarshal,
+	}
+)
+
+// RegisterDecoder registers fn for a file extension such as ".hcl" or for a content type such as "application/hcl".
+func RegisterDecoder(key string, fn Decoder) {
+	decoderMu.Lock()
+	defer decoderMu.Unlock()
+	decoders[strings.ToLower(key)] = fn
+}
+
+func LookupDecoder(key string) (Decoder, bool) {
+	decoderMu.RLock()
+	defer decoderMu.RUnlock()
+	fn, ok := decoders[strings.ToLower(key)]
+	return fn, ok
+}
+
+// Decoder returns the decoder for the extension of Path, then for the content type, and falls back to JSON.
+func (f *File) Decoder() Decoder {
+	p := f.Path
+	if index := strings.LastIndex(p, "?"); index >= 0 {
+		p = p[:index]
+	}
+	if ext := path.Ext(p); ext != "" {
+		if fn, ok := LookupDecoder(ext); ok {
+			return fn
+		}
+	}
+	if v, ok := f.ContentType(); ok {
+		if mediaType, _, err := mime.ParseMediaType(v); err == nil {
+			if fn, ok := LookupDecoder(mediaType); ok {
+				return fn
+			}
+		}
+	}
+	return json.Unmarshal
+}
+
+func (f *File) Decode(obj any) error {
+	return f.Decoder()(f.body, obj)
+}
@@ -0,0 +1,135 @@
+package fileloaders
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// WithDedup wraps every loader registered before it with a DedupLoader.
+func WithDedup() Option {
+	return WithMiddleware(Dedup())
+}
+
+// Dedup returns a Middleware that wraps loaders with NewDedupLoader.
+func Dedup() Middleware {
+	return func(loader Loader) Loader {
+		return NewDedupLoader(loader)
+	}
+}
+
+// DedupLoader shares one backend request between concurrent Load calls for the same path and options,
+// such as the revalidations of an expired CachingLoader entry. Calls with loader specific options are not shared.
+// The shared request is detached from the callers and canceled once every caller has given up.
+type DedupLoader struct {
+	loader Loader
+	mu     sync.Mutex
+	calls  map[string]*dedupCall
+}
+
+type dedupCall struct {
+	done    chan struct{}
+	file    *File
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func NewDedupLoader(loader Loader) *DedupLoader {
+	return &DedupLoader{
+		loader: loader,
+		calls:  make(map[string]*dedupCall),
+	}
+}
+
+func (d *DedupLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+	o := NewOptions(opt...)
+	if !o.Comparable() {
+		return d.loader.Load(ctx, path, opt...)
+	}
+	key := path
+	if v := o.Key(); v != "" {
+		key += "\x00" + v
+	}
+	d.mu.Lock()
+	call, ok := d.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &dedupCall{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		d.calls[key] = call
+		go func() {
+			defer cancel()
+			call.file, call.err = d.loader.Load(callCtx, path, opt...)
+			d.mu.Lock()
+			if d.calls[key] == call {
+				delete(d.calls, key)
+			}
+			d.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	d.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		return call.file.clone(), nil
+	case <-ctx.Done():
+		d.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			if d.calls[key] == call {
+				delete(d.calls, key)
+			}
+		}
+		d.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (d *DedupLoader) unwrap() Loader {
+	return d.loader
+}
+
+func (d *DedupLoader) LoadBatch(ctx context.Context, paths []string, opt ...LoaderOption) (map[string]*File, error) {
+	return loadBatch(ctx, d.loader, paths, opt...)
+}
+
+func (d *DedupLoader) List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
+	return d.loader.List(ctx, path, opt...)
+}
+
+func (d *DedupLoader) Open(ctx context.Context, path string, opt ...LoaderOption) (io.ReadCloser, *File, error) {
+	return open(ctx, d.loader, path, opt...)
+}
+
+func (d *DedupLoader) Save(ctx context.Context, path string, body io.Reader, opt ...LoaderOption) (*File, error) {
+	return save(ctx, d.loader, path, body, opt...)
+}
+
+func (d *DedupLoader) Stat(ctx context.Context, path string, opt ...LoaderOption) (*FileInfo, error) {
+	return stat(ctx, d.loader, path, opt...)
+}
+
+func (d *DedupLoader) Delete(ctx context.Context, path string, opt ...LoaderOption) error {
+	return remove(ctx, d.loader, path, opt...)
+}
+
+func (d *DedupLoader) ListEntries(ctx context.Context, path string, opt ...LoaderOption) ([]Entry, error) {
+	return listEntries(ctx, d.loader, path, opt...)
+}
+
+func (d *DedupLoader) Walk(ctx context.Context, path string, fn WalkFunc, opt ...LoaderOption) error {
+	return walk(ctx, d.loader, path, fn, opt...)
+}
+
+func (d *DedupLoader) Watch(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error) {
+	return watch(ctx, d.loader, path, opt...)
+}
@@ -0,0 +1,116 @@
+package fileloaders
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DiskStore is a CacheStore that keeps every entry as a body file and a JSON metadata file
+// under dir, so that cached files survive restarts. Write failures are ignored.
+type DiskStore struct {
+	dir string
+}
+
+type diskMetadata struct {
+	URI         string    `json:"uri"`
+	Type        string    `json:"type"`
+	Bucket      string    `json:"bucket"`
+	Path        string    `json:"path"`
+	Hash        *string   `json:"hash,omitempty"`
+	Version     *string   `json:"version,omitempty"`
+	ContentType *string   `json:"content_type,omitempty"`
+	ETag        *string   `json:"etag,omitempty"`
+	ModTime     time.Time `json:"mod_time"`
+	Checksum    string    `json:"checksum"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, NewLoadError("file", dir, nil, err)
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+func (s *DiskStore) name(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *DiskStore) Get(key string) (*CacheEntry, bool) {
+	name := s.name(key)
+	b, err := os.ReadFile(name + ".json")
+	if err != nil {
+		return nil, false
+	}
+	var meta diskMetadata
+	if err = json.Unmarshal(b, &meta); err != nil || meta.URI != key {
+		return nil, false
+	}
+	body, err := os.ReadFile(name + ".body")
+	if err != nil {
+		return nil, false
+	}
+	if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != meta.Checksum {
+		return nil, false
+	}
+	file := &File{
+		Type:   meta.Type,
+		Bucket: meta.Bucket,
+		Path:   meta.Path,
+	}
+	file.Add(
+		WithHash(meta.Hash),
+		WithVersion(meta.Version),
+		WithContentType(meta.ContentType),
+		WithETag(meta.ETag),
+		WithModTime(meta.ModTime),
+	)
+	return &CacheEntry{
+		File:      file.WriteBody(body),
+		FetchedAt: meta.FetchedAt,
+		ExpiresAt: meta.ExpiresAt,
+	}, true
+}
+
+// Set writes the body before the metadata, so a reader never sees metadata for a body that was not written.
+func (s *DiskStore) Set(key string, entry *CacheEntry) {
+	ctx := context.Background()
+	name := s.name(key)
+	sum := sha256.Sum256(entry.File.body)
+	meta := diskMetadata{
+		URI:         key,
+		Type:        entry.File.Type,
+		Bucket:      entry.File.Bucket,
+		Path:        entry.File.Path,
+		Hash:        entry.File.hash,
+		Version:     entry.File.version,
+		ContentType: entry.File.contentType,
+		ETag:        entry.File.etag,
+		ModTime:     entry.File.modTime,
+		Checksum:    hex.EncodeToString(sum[:]),
+		FetchedAt:   entry.FetchedAt,
+		ExpiresAt:   entry.ExpiresAt,
+	}
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	if _, err = SaveFile(ctx, name+".body", bytes.NewReader(entry.File.body)); err != nil {
+		return
+	}
+	_, _ = SaveFile(ctx, name+".json", bytes.NewReader(b))
+}
+
+func (s *DiskStore) Delete(key string) {
+	name := s.name(key)
+	_ = os.Remove(name + ".json")
+	_ = os.Remove(name + ".body")
+}
@@ -0,0 +1,30 @@
+package dotenvdecoder
+
+import (
+	"encoding/json"
+
+	"github.com/goccha/fileloaders"
+	"github.com/joho/godotenv"
+)
+
+// Decode parses dotenv data. A *map[string]string is filled directly,
+// any other value is populated through its json tags.
+func Decode(data []byte, v any) error {
+	values, err := godotenv.UnmarshalBytes(data)
+	if err != nil {
+		return err
+	}
+	if m, ok := v.(*map[string]string); ok {
+		*m = values
+		return nil
+	}
+	bin, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bin, v)
+}
+
+func Register() {
+	fileloaders.RegisterDecoder(".env", Decode)
+}
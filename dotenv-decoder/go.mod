@@ -0,0 +1,10 @@
+module github.com/goccha/fileloaders/dotenv-decoder
+
+go 1.21
+
+require (
+	github.com/goccha/fileloaders v0.0.1-alpha.7
+	github.com/joho/godotenv v1.5.1
+)
+
+replace github.com/goccha/fileloaders => ../
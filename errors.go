@@ -0,0 +1,116 @@
+package fileloaders
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNotSupported = errors.New("does not supported")
+	ErrNotExist     = fs.ErrNotExist
+	ErrPermission   = fs.ErrPermission
+	ErrTimeout      = errors.New("timeout")
+	ErrNotModified  = errors.New("not modified")
+	ErrTransient    = errors.New("transient")
+)
+
+// LoadError records the scheme and path of a failed operation together with the backend error.
+// Kind is one of ErrNotExist, ErrPermission, ErrTimeout, ErrNotModified or ErrTransient, or nil when the error is not classified.
+// RetryAfter is the delay requested by the backend before the next attempt, if any.
+type LoadError struct {
+	Scheme     string
+	Path       string
+	Kind       error
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoadError) Error() string {
+	path := e.Path
+	if e.Scheme != "" {
+		path = e.Scheme + "://" + path
+	}
+	return path + ": " + e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// NewLoadError wraps err into a *LoadError. When kind is nil it is derived from err.
+// A nil err, ErrNotSupported and errors that are already a *LoadError are returned as is.
+func NewLoadError(scheme, path string, kind, err error) error {
+	if err == nil || errors.Is(err, ErrNotSupported) {
+		return err
+	}
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		return err
+	}
+	if kind == nil {
+		kind = errorKind(err)
+	}
+	loadErr = &LoadError{
+		Scheme: scheme,
+		Path:   path,
+		Kind:   kind,
+		Err:    err,
+	}
+	var retry interface{ RetryAfter() time.Duration }
+	if errors.As(err, &retry) {
+		loadErr.RetryAfter = retry.RetryAfter()
+	}
+	return loadErr
+}
+
+// IsRetryable reports whether err is a timeout or a transient backend failure.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrTransient) || errors.Is(err, ErrTimeout)
+}
+
+// StatusKind maps an HTTP status code to ErrNotExist, ErrPermission, ErrTimeout, ErrNotModified or ErrTransient.
+func StatusKind(statusCode int) error {
+	switch statusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return ErrNotExist
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrPermission
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrTimeout
+	case http.StatusNotModified:
+		return ErrNotModified
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return ErrTransient
+	}
+	return nil
+}
+
+func errorKind(err error) error {
+	switch {
+	case errors.Is(err, ErrNotExist):
+		return ErrNotExist
+	case errors.Is(err, ErrPermission):
+		return ErrPermission
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, ErrNotModified):
+		return ErrNotModified
+	case errors.Is(err, ErrTransient):
+		return ErrTransient
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return ErrTimeout
+	}
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		return StatusKind(status.HTTPStatusCode())
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package fileloaders
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+type FallbackOption func(f *FallbackLoader)
+
+// WithSkipTransient also moves on to the next candidate when a candidate fails with a retryable error,
+// such as a timeout, a 5xx response or throttling.
+func WithSkipTransient(skip bool) FallbackOption {
+	return func(f *FallbackLoader) {
+		f.skipTransient = skip
+	}
+}
+
+// WithFallback registers a FallbackLoader for scheme. A path such as "cfg://app.yaml" is loaded
+// from each prefix followed by "app.yaml", in order, with the loaders registered in the same MapLoader.
+func WithFallback(scheme string, prefixes []string, opts ...FallbackOption) Option {
+	return func(m map[string]Loader) {
+		m[scheme] = NewFallbackLoader(prefixes, opts...)
+	}
+}
+
+// FallbackLoader tries a list of candidate URIs and returns the first one that can be loaded.
+// Candidates that do not exist or are not supported are skipped; other errors are returned immediately.
+type FallbackLoader struct {
+	prefixes      []string
+	loader        *MapLoader
+	skipTransient bool
+}
+
+func NewFallbackLoader(prefixes []string, opts ...FallbackOption) *FallbackLoader {
+	f := &FallbackLoader{
+		prefixes: prefixes,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func (f *FallbackLoader) candidates(path string) []string {
+	if index := strings.Index(path, "://"); index >= 0 {
+		path = path[index+3:]
+	}
+	result := make([]string, len(f.prefixes))
+	for i, v := range f.prefixes {
+		result[i] = v + path
+	}
+	return result
+}
+
+// bind makes the loader use the first MapLoader it is registered in. Unbound loaders use FromContext.
+func (f *FallbackLoader) bind(m *MapLoader) {
+	if f.loader == nil {
+		f.loader = m
+	}
+}
+
+func (f *FallbackLoader) mapLoader(ctx context.Context) *MapLoader {
+	if f.loader != nil {
+		return f.loader
+	}
+	return FromContext(ctx)
+}
+
+func (f *FallbackLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+	m := f.mapLoader(ctx)
+	return first(f.candidates(path), f.skipTransient, func(candidate string) (*File, error) {
+		return loadOrFile(ctx, m, candidate, opt...)
+	})
+}
+
+func (f *FallbackLoader) List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
+	m := f.mapLoader(ctx)
+	var result []string
+	_, err := first(f.candidates(path), f.skipTransient, func(candidate string) (*File, error) {
+		v, err := listOrFile(ctx, m, candidate, opt...)
+		result = v
+		return nil, err
+	})
+	return result, err
+}
+
+// LoadFirst loads the first of paths that exists, skipping the ones that do not exist or are not supported.
+// The returned File reports the path it was loaded from with Source.
+func LoadFirst(ctx context.Context, paths ...string) (*File, error) {
+	return LoadFirstWith(ctx, paths)
+}
+
+// LoadFirstWith is LoadFirst configured with FallbackOptions, such as WithSkipTransient.
+func LoadFirstWith(ctx context.Context, paths []string, opts ...FallbackOption) (*File, error) {
+	f := NewFallbackLoader(nil, opts...)
+	return first(paths, f.skipTransient, func(candidate string) (*File, error) {
+		return Load(ctx, candidate)
+	})
+}
+
+func first(candidates []string, skipTransient bool, fn func(candidate string) (*File, error)) (*File, error) {
+	var errs []error
+	for _, v := range candidates {
+		file, err := fn(v)
+		if err == nil {
+			if file != nil {
+				file.Add(WithSource(v))
+			}
+			return file, nil
+		}
+		if !skippable(err, skipTransient) {
+			return nil, err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, ErrNotExist
+	}
+	return nil, errors.Join(errs...)
+}
+
+func skippable(err error, skipTransient bool) bool {
+	if errors.Is(err, ErrNotExist) || errors.Is(err, ErrNotSupported) {
+		return true
+	}
+	return skipTransient && IsRetryable(err)
+}
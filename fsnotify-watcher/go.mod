@@ -0,0 +1,12 @@
+module github.com/goccha/fileloaders/fsnotify-watcher
+
+go 1.21
+
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/goccha/fileloaders v0.0.1-alpha.7
+)
+
+require golang.org/x/sys v0.4.0 // indirect
+
+replace github.com/goccha/fileloaders => ../
@@ -0,0 +1,100 @@
+package fsnotifywatcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/goccha/fileloaders"
+)
+
+// Register makes fileloaders.WatchFile, and so fileloaders.Watch for local files, use Watch.
+func Register() {
+	fileloaders.RegisterFileWatcher(Watch)
+}
+
+// Watch watches a local file with fsnotify. The parent directory is watched,
+// so files replaced by a rename (SaveFile, editors, Kubernetes ConfigMaps) are reported as well.
+func Watch(ctx context.Context, path string, opt ...fileloaders.WatchOption) (<-chan fileloaders.Event, error) {
+	o := fileloaders.NewWatchOptions(opt...)
+	name := filepath.Clean(strings.TrimPrefix(path, "file://"))
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fileloaders.NewLoadError("file", name, nil, err)
+	}
+	if err = w.Add(filepath.Dir(name)); err != nil {
+		_ = w.Close()
+		return nil, fileloaders.NewLoadError("file", name, nil, err)
+	}
+	check := func() (string, *fileloaders.File, error) {
+		file, err := fileloaders.LoadFile(ctx, name)
+		if err != nil {
+			return "", nil, err
+		}
+		sum := sha256.Sum256(file.GetBody())
+		return hex.EncodeToString(sum[:]), file, nil
+	}
+	last, _, err := check()
+	if err != nil && !errors.Is(err, fileloaders.ErrNotExist) {
+		_ = w.Close()
+		return nil, err
+	}
+	ch := make(chan fileloaders.Event, 1)
+	go func() {
+		defer close(ch)
+		defer func() {
+			_ = w.Close()
+		}()
+		timer := time.NewTimer(0)
+		if !timer.Stop() {
+			<-timer.C
+		}
+		for {
+			var event *fileloaders.Event
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) == name || filepath.Base(ev.Name) == "..data" {
+					timer.Reset(o.Debounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				event = &fileloaders.Event{Kind: fileloaders.EventError, Path: path, Err: fileloaders.NewLoadError("file", name, nil, err)}
+			case <-timer.C:
+				current, file, err := check()
+				switch {
+				case errors.Is(err, fileloaders.ErrNotExist):
+					if last != "" {
+						event = &fileloaders.Event{Kind: fileloaders.EventRemoved, Path: path}
+					}
+					last = ""
+				case err != nil:
+					event = &fileloaders.Event{Kind: fileloaders.EventError, Path: path, Err: err}
+				case current != last:
+					event = &fileloaders.Event{Kind: fileloaders.EventChanged, Path: path, File: file}
+					last = current
+				}
+			}
+			if event != nil {
+				select {
+				case ch <- *event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
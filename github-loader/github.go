@@ -2,17 +2,21 @@ package githubloader
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/goccha/fileloaders"
 	"github.com/google/go-github/v66/github"
 )
 
 type Loader struct {
-	client *github.Client
+	client  *github.Client
+	message string
 }
 
 type LoaderBuilder struct {
@@ -27,7 +31,7 @@ func (b *LoaderBuilder) Load(ctx context.Context, path string, opt ...fileloader
 	if loader.client == nil {
 		loader.client = b.client
 	}
-	return loader.Load(ctx, path)
+	return loader.Load(ctx, path, opt...)
 }
 func (b *LoaderBuilder) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
 	loader := &Loader{}
@@ -37,7 +41,54 @@ func (b *LoaderBuilder) List(ctx context.Context, path string, opt ...fileloader
 	if loader.client == nil {
 		loader.client = b.client
 	}
-	return loader.List(ctx, path)
+	return loader.List(ctx, path, opt...)
+}
+func (b *LoaderBuilder) Walk(ctx context.Context, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	loader := &Loader{}
+	for _, v := range opt {
+		v(loader)
+	}
+	if loader.client == nil {
+		loader.client = b.client
+	}
+	return loader.Walk(ctx, path, fn, opt...)
+}
+func (b *LoaderBuilder) ListEntries(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	loader := &Loader{}
+	for _, v := range opt {
+		v(loader)
+	}
+	if loader.client == nil {
+		loader.client = b.client
+	}
+	return loader.ListEntries(ctx, path, opt...)
+}
+
+func (b *LoaderBuilder) Save(ctx context.Context, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return Save(ctx, b.client, path, body, opt...)
+}
+
+func (b *LoaderBuilder) Stat(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.FileInfo, error) {
+	loader := &Loader{}
+	for _, v := range opt {
+		v(loader)
+	}
+	if loader.client == nil {
+		loader.client = b.client
+	}
+	return loader.Stat(ctx, path, opt...)
+}
+
+func (b *LoaderBuilder) Delete(ctx context.Context, path string, opt ...fileloaders.LoaderOption) error {
+	return Delete(ctx, b.client, path, opt...)
+}
+
+func WithMessage(message string) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.message = message
+		}
+	}
 }
 
 func WithAuthToken(token string) fileloaders.LoaderOption {
@@ -50,64 +101,375 @@ func WithAuthToken(token string) fileloaders.LoaderOption {
 
 type Builder func() *github.Client
 
-func Load(ctx context.Context, c *github.Client, path string) (*fileloaders.File, error) {
+func wrapError(file *fileloaders.File, err error) error {
+	var kind error
+	var retryAfter time.Duration
+	var errRes *github.ErrorResponse
+	var rateLimit *github.RateLimitError
+	var abuseRateLimit *github.AbuseRateLimitError
+	if errors.As(err, &rateLimit) {
+		kind = fileloaders.ErrTransient
+		retryAfter = time.Until(rateLimit.Rate.Reset.Time)
+	} else if errors.As(err, &abuseRateLimit) {
+		kind = fileloaders.ErrTransient
+		retryAfter = abuseRateLimit.GetRetryAfter()
+	} else if errors.As(err, &errRes) && errRes.Response != nil {
+		kind = fileloaders.StatusKind(errRes.Response.StatusCode)
+	}
+	err = fileloaders.NewLoadError(file.Type, file.Bucket+"/"+file.Path, kind, err)
+	var loadErr *fileloaders.LoadError
+	if retryAfter > 0 && errors.As(err, &loadErr) {
+		loadErr.RetryAfter = retryAfter
+	}
+	return err
+}
+
+func Load(ctx context.Context, c *github.Client, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
 	file := fileloaders.Parse(path)
 	if file == nil || file.Type != "github" || file.Bucket == "" {
 		return nil, fileloaders.ErrNotSupported
 	}
-	repoIndex := strings.Index(file.Path, "/")
-	repo := file.Path[:repoIndex]
-	filepath := file.Path[repoIndex+1:]
-	var opts *github.RepositoryContentGetOptions
-	if index := strings.LastIndex(filepath, "?"); index > 0 {
-		if query, err := url.ParseQuery(filepath[index+1:]); err != nil {
-			return nil, err
-		} else if query.Has("ref") {
-			opts = &github.RepositoryContentGetOptions{Ref: query.Get("ref")}
+	repo, filepath, ref, err := contentPath(file)
+	if err != nil {
+		return nil, err
+	}
+	var fileContent *github.RepositoryContent
+	var res *github.Response
+	if prev := fileloaders.NewOptions(opt...).Previous; prev != nil {
+		if etag, ok := prev.ETag(); ok {
+			fileContent, res, err = getContentsIfNoneMatch(ctx, c, file.Bucket, repo, filepath, ref, etag)
 		}
-		filepath = filepath[:index]
 	}
-	fileContent, _, res, err := c.Repositories.GetContents(ctx, file.Bucket, repo, filepath, opts)
+	if res == nil && err == nil {
+		var opts *github.RepositoryContentGetOptions
+		if ref != "" {
+			opts = &github.RepositoryContentGetOptions{Ref: ref}
+		}
+		fileContent, _, res, err = c.Repositories.GetContents(ctx, file.Bucket, repo, filepath, opts)
+	}
 	if err != nil {
-		return nil, err
+		return nil, wrapError(file, err)
 	}
 	if res.StatusCode != http.StatusOK {
-		return nil, errors.New(res.Status)
+		return nil, wrapError(file, errors.New(res.Status))
+	}
+	if fileContent == nil {
+		return nil, wrapError(file, errIsDir)
 	}
 	v, err := fileContent.GetContent()
 	if err != nil {
 		return nil, err
 	}
-	return file.Add(fileloaders.WithHash(fileContent.SHA)).WriteBody([]byte(v)), nil
+	file.Add(fileloaders.WithHash(fileContent.SHA))
+	if etag := res.Header.Get("ETag"); etag != "" {
+		file.Add(fileloaders.WithETag(&etag))
+	}
+	return file.WriteBody([]byte(v)), nil
 }
 
-func List(ctx context.Context, c *github.Client, path string) ([]string, error) {
-	filePath := fileloaders.Parse(path)
-	if filePath == nil || filePath.Type != "github" || filePath.Bucket == "" {
+// getContentsIfNoneMatch fetches a file with an If-None-Match header; GitHub answers 304 when the ETag is unchanged.
+func getContentsIfNoneMatch(ctx context.Context, c *github.Client, owner, repo, filepath, ref, etag string) (*github.RepositoryContent, *github.Response, error) {
+	u := "repos/" + owner + "/" + repo + "/contents/" + (&url.URL{Path: strings.TrimSuffix(filepath, "/")}).EscapedPath()
+	if ref != "" {
+		u += "?ref=" + url.QueryEscape(ref)
+	}
+	req, err := c.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("If-None-Match", etag)
+	var raw json.RawMessage
+	res, err := c.Do(ctx, req, &raw)
+	if err != nil {
+		return nil, res, err
+	}
+	if len(raw) > 0 && raw[0] == '[' {
+		return nil, res, nil
+	}
+	content := &github.RepositoryContent{}
+	if err = json.Unmarshal(raw, content); err != nil {
+		return nil, res, err
+	}
+	return content, res, nil
+}
+
+func contentPath(file *fileloaders.File) (repo, filepath, ref string, err error) {
+	repo, filepath, _ = strings.Cut(file.Path, "/")
+	if repo == "" {
+		return "", "", "", fileloaders.ErrNotSupported
+	}
+	return repo, filepath, file.URI().Get("ref"), nil
+}
+
+// treePath splits a listing path into the repository, the ref and the directory to list.
+// The ref is read from the "ref" query parameter or, when it is absent, from the first path segment.
+func treePath(file *fileloaders.File) (repo, ref, dir string, err error) {
+	repo, dir, ref, err = contentPath(file)
+	if err != nil {
+		return "", "", "", err
+	}
+	if ref == "" {
+		ref, dir, _ = strings.Cut(dir, "/")
+	}
+	if ref == "" {
+		return "", "", "", fileloaders.ErrNotSupported
+	}
+	return repo, ref, strings.Trim(dir, "/"), nil
+}
+
+func Save(ctx context.Context, c *github.Client, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "github" || file.Bucket == "" {
 		return nil, fileloaders.ErrNotSupported
 	}
-	repoIndex := strings.Index(filePath.Path, "/")
-	repo := filePath.Path[:repoIndex]
-	shaIndex := strings.Index(filePath.Path[repoIndex+1:], "/")
-	sha := ""
-	if shaIndex < 0 {
-		sha = filePath.Path[repoIndex+1:]
+	loader := &Loader{}
+	for _, v := range opt {
+		v(loader)
+	}
+	if loader.client == nil {
+		loader.client = c
+	}
+	repo, filepath, ref, err := contentPath(file)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	opts := &github.RepositoryContentFileOptions{
+		Content: content,
+	}
+	if ref != "" {
+		opts.Branch = github.String(ref)
+	}
+	var getOpts *github.RepositoryContentGetOptions
+	if ref != "" {
+		getOpts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	current, _, res, err := loader.client.Repositories.GetContents(ctx, file.Bucket, repo, filepath, getOpts)
+	if err != nil && (res == nil || res.StatusCode != http.StatusNotFound) {
+		return nil, wrapError(file, err)
+	}
+	var result *github.RepositoryContentResponse
+	if current != nil {
+		opts.SHA = current.SHA
+		opts.Message = github.String(loader.commitMessage("Update " + filepath))
+		result, _, err = loader.client.Repositories.UpdateFile(ctx, file.Bucket, repo, filepath, opts)
 	} else {
-		sha = filePath.Path[repoIndex+1 : shaIndex]
+		opts.Message = github.String(loader.commitMessage("Create " + filepath))
+		result, _, err = loader.client.Repositories.CreateFile(ctx, file.Bucket, repo, filepath, opts)
+	}
+	if err != nil {
+		return nil, wrapError(file, err)
 	}
+	return file.Add(
+		fileloaders.WithHash(result.Content.SHA),
+		fileloaders.WithVersion(result.Commit.SHA)), nil
+}
 
-	tree, res, err := c.Git.GetTree(ctx, filePath.Bucket, repo, sha, false)
+func Stat(ctx context.Context, c *github.Client, path string) (*fileloaders.FileInfo, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "github" || file.Bucket == "" {
+		return nil, fileloaders.ErrNotSupported
+	}
+	repo, filepath, ref, err := contentPath(file)
 	if err != nil {
 		return nil, err
 	}
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	fileContent, dirContent, _, err := c.Repositories.GetContents(ctx, file.Bucket, repo, filepath, opts)
+	if err != nil {
+		return nil, wrapError(file, err)
+	}
+	info := &fileloaders.FileInfo{
+		Type:   file.Type,
+		Bucket: file.Bucket,
+		Path:   filepath,
+		IsDir:  fileContent == nil && dirContent != nil,
+	}
+	if fileContent != nil {
+		info.Size = int64(fileContent.GetSize())
+		info.Hash = fileContent.GetSHA()
+	}
+	return info, nil
+}
+
+func Delete(ctx context.Context, c *github.Client, path string, opt ...fileloaders.LoaderOption) error {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "github" || file.Bucket == "" {
+		return fileloaders.ErrNotSupported
+	}
+	loader := &Loader{}
+	for _, v := range opt {
+		v(loader)
+	}
+	if loader.client == nil {
+		loader.client = c
+	}
+	repo, filepath, ref, err := contentPath(file)
+	if err != nil {
+		return err
+	}
+	var getOpts *github.RepositoryContentGetOptions
+	if ref != "" {
+		getOpts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	current, _, _, err := loader.client.Repositories.GetContents(ctx, file.Bucket, repo, filepath, getOpts)
+	if err != nil {
+		return wrapError(file, err)
+	}
+	if current == nil {
+		return wrapError(file, errIsDir)
+	}
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String(loader.commitMessage("Delete " + filepath)),
+		SHA:     current.SHA,
+	}
+	if ref != "" {
+		opts.Branch = github.String(ref)
+	}
+	_, _, err = loader.client.Repositories.DeleteFile(ctx, file.Bucket, repo, filepath, opts)
+	return wrapError(file, err)
+}
+
+func (l *Loader) commitMessage(defaultMessage string) string {
+	if l.message != "" {
+		return l.message
+	}
+	return defaultMessage
+}
+
+func List(ctx context.Context, c *github.Client, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	entries, err := ListEntries(ctx, c, path, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return fileloaders.EntryNames(entries), nil
+}
+
+func ListEntries(ctx context.Context, c *github.Client, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return fileloaders.CollectEntries(func(fn fileloaders.WalkFunc) error {
+		return Walk(ctx, c, path, fn, opt...)
+	})
+}
+
+func Walk(ctx context.Context, c *github.Client, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	filePath := fileloaders.Parse(path)
+	if filePath == nil || filePath.Type != "github" || filePath.Bucket == "" {
+		return fileloaders.ErrNotSupported
+	}
+	repo, ref, dir, err := treePath(filePath)
+	if err != nil {
+		return err
+	}
+	w := &treeWalker{
+		client: c,
+		file:   filePath,
+		repo:   repo,
+		ref:    ref,
+		fn:     fn,
+	}
+	sha := ref
+	if dir != "" {
+		sha = ref + ":" + dir
+		w.base = dir + "/"
+	}
+	if err = w.walk(ctx, sha, "", fileloaders.NewOptions(opt...).Recursive); err != nil && !errors.Is(err, fileloaders.SkipAll) {
+		return err
+	}
+	return nil
+}
+
+var (
+	errTruncated = errors.New("tree is truncated")
+	errIsDir     = errors.New("is a directory")
+)
+
+// treeWalker reports entry names relative to the listed directory base, and dir relative to base.
+type treeWalker struct {
+	client *github.Client
+	file   *fileloaders.File
+	repo   string
+	ref    string
+	base   string
+	fn     fileloaders.WalkFunc
+}
+
+// walk lists the tree identified by sha. A truncated recursive tree is walked again one level at a time.
+func (w *treeWalker) walk(ctx context.Context, sha, dir string, recursive bool) error {
+	tree, err := w.tree(ctx, sha, recursive)
+	if err != nil {
+		return err
+	}
+	if tree.GetTruncated() {
+		if !recursive {
+			return wrapError(w.file, errTruncated)
+		}
+		return w.walkLevel(ctx, sha, dir)
+	}
+	for _, v := range tree.Entries {
+		if err = w.fn(w.entry(dir, v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *treeWalker) walkLevel(ctx context.Context, sha, dir string) error {
+	tree, err := w.tree(ctx, sha, false)
+	if err != nil {
+		return err
+	}
+	if tree.GetTruncated() {
+		return wrapError(w.file, errTruncated)
+	}
+	for _, v := range tree.Entries {
+		entry := w.entry(dir, v)
+		if err = w.fn(entry); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if err = w.walk(ctx, v.GetSHA(), entry.Name+"/", true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *treeWalker) tree(ctx context.Context, sha string, recursive bool) (*github.Tree, error) {
+	tree, res, err := w.client.Git.GetTree(ctx, w.file.Bucket, w.repo, sha, recursive)
+	if err != nil {
+		return nil, wrapError(w.file, err)
+	}
 	if res.StatusCode != http.StatusOK {
-		return nil, errors.New(res.Status)
+		return nil, wrapError(w.file, errors.New(res.Status))
+	}
+	return tree, nil
+}
+
+func (w *treeWalker) entry(dir string, v *github.TreeEntry) fileloaders.Entry {
+	name := dir + v.GetPath()
+	entry := fileloaders.Entry{
+		Name: name,
+		URI: (&fileloaders.URI{
+			Scheme: "github",
+			Bucket: w.file.Bucket,
+			Key:    w.repo + "/" + w.base + name,
+			Query:  url.Values{"ref": {w.ref}},
+		}).String(),
+		Size: int64(v.GetSize()),
+		Hash: v.GetSHA(),
+		Kind: fileloaders.KindFile,
 	}
-	result := make([]string, len(tree.Entries))
-	for i, v := range tree.Entries {
-		result[i] = *v.Path
+	if v.GetType() == "tree" {
+		entry.Kind = fileloaders.KindDir
 	}
-	return result, nil
+	return entry
 }
 
 func New(c *github.Client) fileloaders.Loader {
@@ -117,11 +479,31 @@ func New(c *github.Client) fileloaders.Loader {
 }
 
 func (l *Loader) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
-	return Load(ctx, l.client, path)
+	return Load(ctx, l.client, path, opt...)
 }
 
 func (l *Loader) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
-	return List(ctx, l.client, path)
+	return List(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) ListEntries(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return ListEntries(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) Walk(ctx context.Context, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	return Walk(ctx, l.client, path, fn, opt...)
+}
+
+func (l *Loader) Save(ctx context.Context, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return Save(ctx, l.client, path, body, append([]fileloaders.LoaderOption{WithMessage(l.message)}, opt...)...)
+}
+
+func (l *Loader) Stat(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.FileInfo, error) {
+	return Stat(ctx, l.client, path)
+}
+
+func (l *Loader) Delete(ctx context.Context, path string, opt ...fileloaders.LoaderOption) error {
+	return Delete(ctx, l.client, path, append([]fileloaders.LoaderOption{WithMessage(l.message)}, opt...)...)
 }
 
 func WithClient(api *github.Client) fileloaders.Option {
@@ -0,0 +1,149 @@
+package fileloaders
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// PrefixLister is implemented by loaders whose keys can be listed by any prefix rather than by directory,
+// such as object stores. Glob walks them from the whole literal prefix of the pattern. Entry names are
+// relative to the directory of the prefix, as Walk reports them relative to the listed directory.
+type PrefixLister interface {
+	WalkPrefix(ctx context.Context, prefix string, fn WalkFunc, opt ...LoaderOption) error
+}
+
+func prefixLister(ctx context.Context, path string) (PrefixLister, bool) {
+	loader, _, ok := FromContext(ctx).lookup(path)
+	if !ok {
+		return nil, false
+	}
+	for {
+		w, ok := loader.(wrapper)
+		if !ok {
+			break
+		}
+		loader = w.unwrap()
+	}
+	v, ok := loader.(PrefixLister)
+	return v, ok
+}
+
+// Glob returns the URIs of the files matching pattern. Besides the path.Match syntax,
+// a "**" segment matches any number of directories. Only the literal prefix of the pattern is
+// listed by the backend, down to its directory unless the loader is a PrefixLister; the rest is
+// matched client-side against the decoded keys.
+// A trailing query such as "?ref=main" is sent with the listing and ignored when matching.
+func Glob(ctx context.Context, pattern string, opt ...LoaderOption) ([]string, error) {
+	pattern, query := splitGlobQuery(pattern)
+	patterns := strings.Split(pattern, "/")
+	for _, v := range patterns {
+		if _, err := path.Match(v, ""); err != nil {
+			return nil, err
+		}
+	}
+	prefix := pattern[:literalPrefix(pattern)]
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	rest := pattern[len(dir):]
+	if strings.Contains(rest, "/") || strings.Contains(rest, "**") {
+		opt = append(opt[:len(opt):len(opt)], WithRecursive(true))
+	}
+	var result []string
+	fn := func(entry Entry) error {
+		if entry.IsDir() {
+			return nil
+		}
+		uri := entry.URI
+		if uri == "" || dir == "" {
+			uri = dir + entry.Name
+		}
+		name := matchName(uri, query != "")
+		if matchSegments(patterns, strings.Split(name, "/")) {
+			result = append(result, uri)
+		}
+		return nil
+	}
+	listDir := dir
+	if listDir == "" {
+		listDir = "."
+	}
+	var err error
+	if lister, ok := prefixLister(ctx, pattern); ok && dir != "" {
+		err = lister.WalkPrefix(ctx, prefix+query, fn, opt...)
+	} else {
+		err = Walk(ctx, listDir+query, fn, opt...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func LoadGlob(ctx context.Context, pattern string, opt ...LoaderOption) ([]*File, error) {
+	list, err := Glob(ctx, pattern, opt...)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*File, 0, len(list))
+	for _, v := range list {
+		file, err := Load(ctx, v, opt...)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, file)
+	}
+	return result, nil
+}
+
+// splitGlobQuery separates a trailing "?key=value" query from the pattern,
+// so that "?" keeps its glob meaning everywhere else.
+func splitGlobQuery(pattern string) (string, string) {
+	index := strings.LastIndex(pattern, "?")
+	if index < 0 || strings.Contains(pattern[index:], "/") || !strings.Contains(pattern[index:], "=") {
+		return pattern, ""
+	}
+	return pattern[:index], pattern[index:]
+}
+
+// matchName returns the name a URI is matched with: the decoded key of a URI, or the path itself.
+func matchName(uri string, hasQuery bool) string {
+	if u, err := ParseURI(uri); err == nil {
+		return u.Scheme + "://" + u.Bucket + "/" + u.Key
+	}
+	if index := strings.LastIndex(uri, "?"); hasQuery && index >= 0 {
+		return uri[:index]
+	}
+	return uri
+}
+
+func literalPrefix(pattern string) int {
+	if index := strings.IndexAny(pattern, "*?[\\"); index >= 0 {
+		return index
+	}
+	return len(pattern)
+}
+
+func matchSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			patterns = patterns[1:]
+			if len(patterns) == 0 {
+				return true
+			}
+			for i := range names {
+				if matchSegments(patterns, names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if ok, err := path.Match(patterns[0], names[0]); err != nil || !ok {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0
+}
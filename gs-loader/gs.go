@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strconv"
+	"strings"
 
 	"cloud.google.com/go/storage"
 	"github.com/goccha/fileloaders"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 )
 
@@ -14,14 +17,74 @@ type Client interface {
 	Bucket(name string) *storage.BucketHandle
 }
 
-func Load(ctx context.Context, api Client, path string) (*fileloaders.File, error) {
+func wrapError(file *fileloaders.File, err error) error {
+	var kind error
+	var apiErr *googleapi.Error
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+		kind = fileloaders.ErrNotExist
+	} else if errors.As(err, &apiErr) {
+		kind = fileloaders.StatusKind(apiErr.Code)
+	}
+	return fileloaders.NewLoadError(file.Type, file.Bucket+"/"+file.Path, kind, err)
+}
+
+// object returns the handle of file, pinned to the generation given by the "?version=" or "?generation=" query parameter.
+func object(api Client, file *fileloaders.File) *storage.ObjectHandle {
+	obj := api.Bucket(file.Bucket).Object(file.Path)
+	u := file.URI()
+	v := u.Get("version")
+	if v == "" {
+		v = u.Get("generation")
+	}
+	if generation, err := strconv.ParseInt(v, 10, 64); err == nil {
+		obj = obj.Generation(generation)
+	}
+	return obj
+}
+
+func entryURI(bucket, name string) string {
+	return (&fileloaders.URI{Scheme: "gs", Bucket: bucket, Key: name}).String()
+}
+
+func Open(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) (io.ReadCloser, *fileloaders.File, error) {
 	file := fileloaders.Parse(path)
 	if file == nil || file.Type != "gs" || file.Bucket == "" {
-		return nil, fileloaders.ErrNotSupported
+		return nil, nil, fileloaders.ErrNotSupported
 	}
-	bucketHandle := api.Bucket(file.Bucket)
-	obj := bucketHandle.Object(file.Path)
-	reader, err := obj.NewReader(ctx)
+	obj := object(api, file)
+	o := fileloaders.NewOptions(opt...)
+	if o.Previous != nil {
+		if v, ok := o.Previous.Version(); ok {
+			if generation, err := strconv.ParseInt(v, 10, 64); err == nil {
+				obj = obj.If(storage.Conditions{GenerationNotMatch: generation})
+			}
+		}
+	}
+	var reader *storage.Reader
+	var err error
+	if o.Range != nil {
+		// NewRangeReader reads to the end with a negative length and the last -offset bytes with a negative offset.
+		length := o.Range.Length
+		if length <= 0 || o.Range.Offset < 0 {
+			length = -1
+		}
+		reader, err = obj.NewRangeReader(ctx, o.Range.Offset, length)
+	} else {
+		reader, err = obj.NewReader(ctx)
+	}
+	if err != nil {
+		return nil, nil, wrapError(file, err)
+	}
+	contentType := reader.Attrs.ContentType
+	generation := strconv.FormatInt(reader.Attrs.Generation, 10)
+	return reader, file.Add(
+		fileloaders.WithVersion(&generation),
+		fileloaders.WithContentType(&contentType),
+		fileloaders.WithOffset(reader.Attrs.StartOffset)), nil
+}
+
+func Load(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	reader, file, err := Open(ctx, api, path, opt...)
 	if err != nil {
 		return nil, err
 	}
@@ -34,38 +97,195 @@ func Load(ctx context.Context, api Client, path string) (*fileloaders.File, erro
 	}
 	return file.WriteBody(body), nil
 }
-func List(ctx context.Context, api Client, path string) ([]string, error) {
-	filePath := fileloaders.Parse(path)
-	if filePath == nil || filePath.Type != "gs" || filePath.Bucket == "" {
+
+func Save(ctx context.Context, api Client, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "gs" || file.Bucket == "" {
+		return nil, fileloaders.ErrNotSupported
+	}
+	loader := New(api)
+	for _, v := range opt {
+		v(loader)
+	}
+	// Closing the writer commits the upload, so a failed copy cancels it instead.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	writer := api.Bucket(file.Bucket).Object(file.Path).NewWriter(ctx)
+	if loader.contentType != "" {
+		writer.ContentType = loader.contentType
+	}
+	if _, err := io.Copy(writer, body); err != nil {
+		cancel()
+		return nil, wrapError(file, err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, wrapError(file, err)
+	}
+	if attrs := writer.Attrs(); attrs != nil {
+		etag := attrs.Etag
+		generation := strconv.FormatInt(attrs.Generation, 10)
+		file = file.Add(fileloaders.WithHash(&etag), fileloaders.WithVersion(&generation))
+	}
+	return file, nil
+}
+
+func Stat(ctx context.Context, api Client, path string) (*fileloaders.FileInfo, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "gs" || file.Bucket == "" {
 		return nil, fileloaders.ErrNotSupported
 	}
-	bucketHandle := api.Bucket(filePath.Bucket)
-	iter := bucketHandle.Objects(ctx, &storage.Query{
-		Prefix: filePath.Path,
+	attrs, err := object(api, file).Attrs(ctx)
+	if err != nil {
+		return nil, wrapError(file, err)
+	}
+	return &fileloaders.FileInfo{
+		Type:    file.Type,
+		Bucket:  file.Bucket,
+		Path:    file.Path,
+		Size:    attrs.Size,
+		ModTime: attrs.Updated,
+		Hash:    attrs.Etag,
+		Version: strconv.FormatInt(attrs.Generation, 10),
+	}, nil
+}
+
+func Delete(ctx context.Context, api Client, path string) error {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "gs" || file.Bucket == "" {
+		return fileloaders.ErrNotSupported
+	}
+	return wrapError(file, object(api, file).Delete(ctx))
+}
+
+func List(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	entries, err := ListEntries(ctx, api, path, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return fileloaders.EntryNames(entries), nil
+}
+
+func ListEntries(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return fileloaders.CollectEntries(func(fn fileloaders.WalkFunc) error {
+		return Walk(ctx, api, path, fn, opt...)
 	})
-	var result []string
+}
+
+// Walk lists the directory path. Entry names are relative to path and subdirectories
+// are listed too when the Recursive option is set.
+func Walk(ctx context.Context, api Client, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	filePath := fileloaders.Parse(path)
+	if filePath == nil || filePath.Type != "gs" || filePath.Bucket == "" {
+		return fileloaders.ErrNotSupported
+	}
+	dir := filePath.Path
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	return walk(ctx, api, filePath, dir, dir, fn, opt...)
+}
+
+// WalkPrefix lists the objects whose names begin with the name of prefix, which need not be a directory.
+// Entry names are relative to the directory of the prefix.
+func WalkPrefix(ctx context.Context, api Client, prefix string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	filePath := fileloaders.Parse(prefix)
+	if filePath == nil || filePath.Type != "gs" || filePath.Bucket == "" {
+		return fileloaders.ErrNotSupported
+	}
+	dir := filePath.Path[:strings.LastIndex(filePath.Path, "/")+1]
+	return walk(ctx, api, filePath, filePath.Path, dir, fn, opt...)
+}
+
+func walk(ctx context.Context, api Client, filePath *fileloaders.File, prefix, dir string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	query := &storage.Query{
+		Prefix: prefix,
+	}
+	if !fileloaders.NewOptions(opt...).Recursive {
+		query.Delimiter = "/"
+	}
+	iter := api.Bucket(filePath.Bucket).Objects(ctx, query)
 	for {
 		obj, err := iter.Next()
 		if errors.Is(err, iterator.Done) {
-			break
+			return nil
 		}
 		if err != nil {
-			return nil, err
+			return wrapError(filePath, err)
 		}
-		result = append(result, obj.Name)
+		if obj.Prefix == "" && obj.Name == dir {
+			// the placeholder object of the directory itself
+			continue
+		}
+		if err = fn(entry(filePath.Bucket, dir, obj)); err != nil {
+			if errors.Is(err, fileloaders.SkipAll) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func entry(bucket, dir string, obj *storage.ObjectAttrs) fileloaders.Entry {
+	if obj.Prefix != "" {
+		return fileloaders.Entry{
+			Name: strings.TrimSuffix(strings.TrimPrefix(obj.Prefix, dir), "/"),
+			URI:  entryURI(bucket, obj.Prefix),
+			Kind: fileloaders.KindDir,
+		}
+	}
+	return fileloaders.Entry{
+		Name:    strings.TrimPrefix(obj.Name, dir),
+		URI:     entryURI(bucket, obj.Name),
+		Size:    obj.Size,
+		ModTime: obj.Updated,
+		Hash:    obj.Etag,
+		Version: strconv.FormatInt(obj.Generation, 10),
+		Kind:    fileloaders.KindFile,
 	}
-	return result, nil
 }
 
 type Loader struct {
-	client Client
+	client      Client
+	contentType string
+}
+
+func WithContentType(contentType string) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.contentType = contentType
+		}
+	}
 }
 
 func (l *Loader) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
-	return Load(ctx, l.client, path)
+	return Load(ctx, l.client, path, opt...)
+}
+func (l *Loader) Open(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (io.ReadCloser, *fileloaders.File, error) {
+	return Open(ctx, l.client, path, opt...)
+}
+func (l *Loader) Save(ctx context.Context, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return Save(ctx, l.client, path, body, opt...)
+}
+func (l *Loader) Stat(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.FileInfo, error) {
+	return Stat(ctx, l.client, path)
+}
+func (l *Loader) Delete(ctx context.Context, path string, opt ...fileloaders.LoaderOption) error {
+	return Delete(ctx, l.client, path)
 }
 func (l *Loader) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
-	return List(ctx, l.client, path)
+	return List(ctx, l.client, path, opt...)
+}
+func (l *Loader) ListEntries(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return ListEntries(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) Walk(ctx context.Context, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	return Walk(ctx, l.client, path, fn, opt...)
+}
+
+// WalkPrefix makes Glob list objects from the whole literal prefix of a pattern.
+func (l *Loader) WalkPrefix(ctx context.Context, prefix string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	return WalkPrefix(ctx, l.client, prefix, fn, opt...)
 }
 
 func New(api Client) *Loader {
@@ -0,0 +1,199 @@
+package httploader
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccha/fileloaders"
+)
+
+// ClientConfig describes the http.Client built by NewClient. CertFile, KeyFile and CAFile are PEM files
+// read with fileloaders.Load, so they may be local paths, "file://" URIs or any registered scheme such as "ssm://".
+// Zero durations keep the defaults of http.DefaultTransport, and an empty ProxyURL uses the proxy environment variables.
+type ClientConfig struct {
+	CertFile              string
+	KeyFile               string
+	CAFile                string
+	ServerName            string
+	InsecureSkipVerify    bool
+	ProxyURL              string
+	Timeout               time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+}
+
+type ClientOption func(o *clientOptions)
+
+type clientOptions struct {
+	reload       bool
+	watchOptions []fileloaders.WatchOption
+	onError      func(err error)
+}
+
+// WithCertReload watches CertFile, KeyFile and CAFile with fileloaders.Watch and rebuilds the transport
+// when they change. New connections use the new certificates; the idle ones of the old transport are closed.
+func WithCertReload(opt ...fileloaders.WatchOption) ClientOption {
+	return func(o *clientOptions) {
+		o.reload = true
+		o.watchOptions = append(o.watchOptions, opt...)
+	}
+}
+
+// WithReloadError is called when reloading the certificates fails. The previous transport is kept.
+func WithReloadError(fn func(err error)) ClientOption {
+	return func(o *clientOptions) {
+		o.onError = fn
+	}
+}
+
+// NewClient builds an http.Client from cfg. When CAFile is set, servers are only trusted if their
+// certificate is signed by one of its CAs. Certificates are reloaded until ctx is done.
+func NewClient(ctx context.Context, cfg ClientConfig, opts ...ClientOption) (*http.Client, error) {
+	o := &clientOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	t := &reloadTransport{config: cfg, proxy: http.ProxyFromEnvironment}
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		t.proxy = http.ProxyURL(u)
+	}
+	if err := t.reload(ctx); err != nil {
+		return nil, err
+	}
+	if o.reload {
+		if err := t.watch(ctx, o); err != nil {
+			return nil, err
+		}
+	}
+	return &http.Client{Transport: t, Timeout: cfg.Timeout}, nil
+}
+
+// TLSConfig loads the certificates of c into a tls.Config.
+func (c ClientConfig) TLSConfig(ctx context.Context) (*tls.Config, error) {
+	conf := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("httploader: client certificate requires both CertFile and KeyFile")
+		}
+		cert, err := fileloaders.Load(ctx, c.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := fileloaders.Load(ctx, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		pair, err := tls.X509KeyPair(cert.GetBody(), key.GetBody())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.CertFile, err)
+		}
+		conf.Certificates = []tls.Certificate{pair}
+	}
+	if c.CAFile != "" {
+		ca, err := fileloaders.Load(ctx, c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca.GetBody()) {
+			return nil, errors.New(c.CAFile + ": no certificate found")
+		}
+		conf.RootCAs = pool
+	}
+	return conf, nil
+}
+
+func (c ClientConfig) transport(conf *tls.Config, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxy
+	t.TLSClientConfig = conf
+	if c.DialTimeout > 0 {
+		t.DialContext = (&net.Dialer{Timeout: c.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+	if c.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = c.TLSHandshakeTimeout
+	}
+	if c.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = c.ResponseHeaderTimeout
+	}
+	if c.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = c.IdleConnTimeout
+	}
+	return t
+}
+
+// reloadTransport sends the requests with the latest transport built from its config.
+type reloadTransport struct {
+	config  ClientConfig
+	proxy   func(*http.Request) (*url.URL, error)
+	mu      sync.Mutex
+	current atomic.Pointer[http.Transport]
+}
+
+func (t *reloadTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current.Load().RoundTrip(req)
+}
+
+func (t *reloadTransport) CloseIdleConnections() {
+	t.current.Load().CloseIdleConnections()
+}
+
+func (t *reloadTransport) reload(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	conf, err := t.config.TLSConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if old := t.current.Swap(t.config.transport(conf, t.proxy)); old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+// watch reloads the transport on every change of the certificate files. A certificate and its key
+// usually change one after the other, so a failed reload is retried on the next event.
+func (t *reloadTransport) watch(ctx context.Context, o *clientOptions) error {
+	seen := make(map[string]bool)
+	for _, path := range []string{t.config.CertFile, t.config.KeyFile, t.config.CAFile} {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		events, err := fileloaders.Watch(ctx, path, o.watchOptions...)
+		if err != nil {
+			return err
+		}
+		go func() {
+			for event := range events {
+				err := event.Err
+				if event.Kind == fileloaders.EventChanged {
+					err = t.reload(ctx)
+				}
+				if err != nil && o.onError != nil {
+					o.onError(err)
+				}
+			}
+		}()
+	}
+	return nil
+}
@@ -1,69 +1,345 @@
 package httploader
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/goccha/fileloaders"
 )
 
+// Client sends the requests of the Loader. *http.Client implements it.
 type Client interface {
-	Get(url string) (resp *http.Response, err error)
+	Do(req *http.Request) (*http.Response, error)
 }
 
-func Load(c Client, path string) (*fileloaders.File, error) {
-	if c == nil {
-		c = http.DefaultClient
+type StatusError struct {
+	StatusCode int
+	Status     string
+	retryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return e.Status
+}
+
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// RetryAfter returns the delay of the Retry-After header of the response.
+func (e *StatusError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func retryAfter(res *http.Response) time.Duration {
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0
 	}
-	u, err := url.Parse(path)
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+func wrapError(u *url.URL, err error) error {
+	return fileloaders.NewLoadError(u.Scheme, u.Host+u.Path, nil, err)
+}
+
+func statusError(u *url.URL, res *http.Response) error {
+	return wrapError(u, &StatusError{StatusCode: res.StatusCode, Status: res.Status, retryAfter: retryAfter(res)})
+}
+
+func Open(ctx context.Context, c Client, path string, opt ...fileloaders.LoaderOption) (io.ReadCloser, *fileloaders.File, error) {
+	return New(c).Open(ctx, path, opt...)
+}
+
+func Load(ctx context.Context, c Client, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return New(c).Load(ctx, path, opt...)
+}
+
+func Stat(ctx context.Context, c Client, path string, opt ...fileloaders.LoaderOption) (*fileloaders.FileInfo, error) {
+	return New(c).Stat(ctx, path, opt...)
+}
+
+func List(ctx context.Context, c Client, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	return New(c).List(ctx, path, opt...)
+}
+
+func ListEntries(ctx context.Context, c Client, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return New(c).ListEntries(ctx, path, opt...)
+}
+
+func Walk(ctx context.Context, c Client, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	return New(c).Walk(ctx, path, fn, opt...)
+}
+
+// CredentialsFunc returns the Authorization header value to send to host, or "" to send none.
+type CredentialsFunc func(ctx context.Context, host string) (string, error)
+
+type Loader struct {
+	client      Client
+	header      http.Header
+	credentials CredentialsFunc
+	listing     Listing
+	manifest    string
+	schema      ManifestSchema
+}
+
+// WithHeader adds a header to every request.
+func WithHeader(key, value string) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.header.Add(key, value)
+		}
+	}
+}
+
+func WithBearerToken(token string) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+func WithBasicAuth(username, password string) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+		}
+	}
+}
+
+// WithCredentials looks up the Authorization header per host on every request. It takes precedence over the static headers.
+func WithCredentials(fn CredentialsFunc) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.credentials = fn
+		}
+	}
+}
+
+// with returns a copy of l with opt applied, so that per-call options do not leak into l.
+func (l *Loader) with(opt ...fileloaders.LoaderOption) *Loader {
+	if len(opt) == 0 {
+		return l
+	}
+	loader := &Loader{
+		client:      l.client,
+		header:      l.header.Clone(),
+		credentials: l.credentials,
+		listing:     l.listing,
+		manifest:    l.manifest,
+		schema:      l.schema,
+	}
+	if loader.header == nil {
+		loader.header = make(http.Header)
+	}
+	for _, v := range opt {
+		v(loader)
+	}
+	return loader
+}
+
+func (l *Loader) newRequest(ctx context.Context, method string, u *url.URL, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
 	if err != nil {
 		return nil, err
 	}
-	res, err := c.Get(path)
+	for k, v := range l.header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if l.credentials != nil {
+		auth, err := l.credentials(ctx, u.Host)
+		if err != nil {
+			return nil, wrapError(u, err)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+	}
+	return req, nil
+}
+
+// do sends the request and returns the response when its status is 2xx.
+// A 304 answer to a conditional request is returned as an error of kind ErrNotModified.
+func (l *Loader) do(ctx context.Context, method, path string, o *fileloaders.Options) (*url.URL, *http.Response, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	header := make(http.Header)
+	if o != nil && o.Previous != nil {
+		if etag, ok := o.Previous.ETag(); ok {
+			header.Set("If-None-Match", etag)
+		}
+		if t, ok := o.Previous.ModTime(); ok {
+			header.Set("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+		}
+	}
+	if o != nil && o.Range != nil {
+		header.Set("Range", o.Range.Header())
+	}
+	res, err := l.send(ctx, method, u, nil, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, res, nil
+}
+
+func (l *Loader) send(ctx context.Context, method string, u *url.URL, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := l.newRequest(ctx, method, u, body)
 	if err != nil {
 		return nil, err
 	}
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.New(res.Status)
+	for k, v := range header {
+		req.Header[k] = v
 	}
-	defer func() {
+	res, err := l.client.Do(req)
+	if err != nil {
+		return nil, wrapError(u, err)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
 		_ = res.Body.Close()
-	}()
-	body, err := io.ReadAll(res.Body)
+		return nil, statusError(u, res)
+	}
+	return res, nil
+}
+
+func (l *Loader) Open(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (io.ReadCloser, *fileloaders.File, error) {
+	o := fileloaders.NewOptions(opt...)
+	u, res, err := l.with(opt...).do(ctx, http.MethodGet, path, o)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	file := &fileloaders.File{
 		Type:   u.Scheme,
 		Bucket: u.Host,
 		Path:   u.Path,
 	}
-	return file.WriteBody(body), nil
+	if etag := res.Header.Get("ETag"); etag != "" {
+		file.Add(fileloaders.WithHash(&etag))
+	}
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		file.Add(fileloaders.WithContentType(&contentType))
+	}
+	if t, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		file.Add(fileloaders.WithModTime(t))
+	}
+	if o.Range != nil {
+		offset, err := slice(res, o.Range)
+		if err != nil {
+			_ = res.Body.Close()
+			return nil, nil, wrapError(u, err)
+		}
+		file.Add(fileloaders.WithOffset(offset))
+	}
+	return res.Body, file, nil
 }
 
-type Loader struct {
-	client Client
+// slice returns the offset of a 206 response. Servers that ignore the Range header answer 200
+// with the whole file, whose body is then cut down to rng.
+func slice(res *http.Response, rng *fileloaders.Range) (int64, error) {
+	if res.StatusCode == http.StatusPartialContent {
+		start, _, _, ok := fileloaders.ParseContentRange(res.Header.Get("Content-Range"))
+		if !ok {
+			return 0, errors.New("invalid Content-Range: " + res.Header.Get("Content-Range"))
+		}
+		return start, nil
+	}
+	offset := rng.Offset
+	if offset < 0 {
+		if res.ContentLength < 0 {
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				return 0, err
+			}
+			_ = res.Body.Close()
+			offset = max(int64(len(body))+offset, 0)
+			res.Body = io.NopCloser(bytes.NewReader(body[offset:]))
+			return offset, nil
+		}
+		offset = max(res.ContentLength+offset, 0)
+	}
+	if _, err := io.CopyN(io.Discard, res.Body, offset); err != nil && err != io.EOF {
+		return 0, err
+	}
+	if rng.Offset >= 0 && rng.Length > 0 {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(res.Body, rng.Length), res.Body}
+	}
+	return offset, nil
 }
 
 func (l *Loader) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
-	return Load(l.client, path)
+	r, file, err := l.Open(ctx, path, opt...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return file.WriteBody(body), nil
 }
-func (l *Loader) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
-	return nil, fileloaders.ErrNotSupported
+
+func (l *Loader) Stat(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.FileInfo, error) {
+	u, res, err := l.with(opt...).do(ctx, http.MethodHead, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.Body.Close()
+	info := &fileloaders.FileInfo{
+		Type:   u.Scheme,
+		Bucket: u.Host,
+		Path:   u.Path,
+		Size:   res.ContentLength,
+		Hash:   res.Header.Get("ETag"),
+	}
+	if v := res.Header.Get("Last-Modified"); v != "" {
+		if t, err := http.ParseTime(v); err == nil {
+			info.ModTime = t
+		}
+	}
+	return info, nil
 }
 
-func New(c Client) *Loader {
-	return &Loader{
-		client: c,
+// New returns a Loader that sends its requests with c, or http.DefaultClient when c is nil.
+func New(c Client, opt ...fileloaders.LoaderOption) *Loader {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	loader := &Loader{
+		client:   c,
+		header:   make(http.Header),
+		manifest: "index.json",
+		schema:   DefaultManifestSchema,
+	}
+	for _, v := range opt {
+		v(loader)
 	}
+	return loader
 }
 
-func With(c Client) fileloaders.Option {
+func With(c Client, opt ...fileloaders.LoaderOption) fileloaders.Option {
 	return func(m map[string]fileloaders.Loader) {
-		loader := New(c)
+		loader := New(c, opt...)
 		m["http"] = loader
 		m["https"] = loader
 	}
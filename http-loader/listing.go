@@ -0,0 +1,343 @@
+package httploader
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccha/fileloaders"
+)
+
+// Listing selects how the Loader lists a directory URL.
+type Listing int
+
+const (
+	// ListingNone makes List return ErrNotSupported.
+	ListingNone Listing = iota
+	// ListingAutoIndex parses the links of an Apache or nginx autoindex page.
+	ListingAutoIndex
+	// ListingManifest reads a JSON manifest, "index.json" by default, in the directory.
+	ListingManifest
+	// ListingWebDAV sends a PROPFIND request with "Depth: 1".
+	ListingWebDAV
+)
+
+// ManifestSchema names the fields of a JSON manifest. Entries is the dot separated path of the
+// entry array, or "" when the manifest itself is the array. An entry may also be a plain string;
+// names ending with "/" are directories.
+type ManifestSchema struct {
+	Entries string
+	Name    string
+	Size    string
+	ModTime string
+	Hash    string
+	Dir     string
+}
+
+var DefaultManifestSchema = ManifestSchema{
+	Name:    "name",
+	Size:    "size",
+	ModTime: "modified",
+	Hash:    "hash",
+	Dir:     "dir",
+}
+
+func WithListing(listing Listing) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.listing = listing
+		}
+	}
+}
+
+// WithManifest lists directories by reading the manifest name with schema.
+func WithManifest(name string, schema ManifestSchema) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.listing = ListingManifest
+			v.manifest = name
+			v.schema = schema
+		}
+	}
+}
+
+func (l *Loader) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	entries, err := l.ListEntries(ctx, path, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return fileloaders.EntryNames(entries), nil
+}
+
+func (l *Loader) ListEntries(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return fileloaders.CollectEntries(func(fn fileloaders.WalkFunc) error {
+		return l.Walk(ctx, path, fn, opt...)
+	})
+}
+
+// Walk lists the directory path. Entry names are relative to path and subdirectories
+// are listed too when the Recursive option is set.
+func (l *Loader) Walk(ctx context.Context, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	loader := l.with(opt...)
+	if loader.listing == ListingNone {
+		return fileloaders.ErrNotSupported
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+	err = loader.walk(ctx, u, "", fileloaders.NewOptions(opt...).Recursive, fn)
+	if errors.Is(err, fileloaders.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func (l *Loader) walk(ctx context.Context, dir *url.URL, prefix string, recursive bool, fn fileloaders.WalkFunc) error {
+	entries, err := l.listDir(ctx, dir)
+	if err != nil {
+		return err
+	}
+	for _, v := range entries {
+		name := v.Name
+		v.Name = prefix + name
+		if err = fn(v); err != nil {
+			return err
+		}
+		if recursive && v.IsDir() {
+			sub, err := url.Parse(v.URI)
+			if err != nil {
+				return err
+			}
+			if err = l.walk(ctx, sub, v.Name+"/", recursive, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Loader) listDir(ctx context.Context, dir *url.URL) ([]fileloaders.Entry, error) {
+	switch l.listing {
+	case ListingAutoIndex:
+		return l.listAutoIndex(ctx, dir)
+	case ListingManifest:
+		return l.listManifest(ctx, dir)
+	case ListingWebDAV:
+		return l.listWebDAV(ctx, dir)
+	}
+	return nil, fileloaders.ErrNotSupported
+}
+
+// child returns the name of ref when it is a direct child of dir, with a trailing "/" for directories.
+func child(dir, ref *url.URL) (string, bool) {
+	if ref.Scheme != dir.Scheme || ref.Host != dir.Host || !strings.HasPrefix(ref.Path, dir.Path) {
+		return "", false
+	}
+	name := strings.TrimPrefix(ref.Path, dir.Path)
+	if name == "" || strings.Contains(strings.TrimSuffix(name, "/"), "/") {
+		return "", false
+	}
+	return name, true
+}
+
+func entry(dir *url.URL, name string) fileloaders.Entry {
+	ref := *dir
+	ref.Path += name
+	ref.RawQuery = ""
+	ref.Fragment = ""
+	e := fileloaders.Entry{
+		Name: strings.TrimSuffix(name, "/"),
+		URI:  ref.String(),
+		Kind: fileloaders.KindFile,
+	}
+	if strings.HasSuffix(name, "/") {
+		e.Kind = fileloaders.KindDir
+	}
+	return e
+}
+
+var hrefPattern = regexp.MustCompile(`(?i)<a\s+[^>]*href="([^"]+)"`)
+
+// listAutoIndex keeps the links of the index page that point to direct children of dir,
+// skipping the parent directory and the sort links.
+func (l *Loader) listAutoIndex(ctx context.Context, dir *url.URL) ([]fileloaders.Entry, error) {
+	res, err := l.send(ctx, http.MethodGet, dir, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, wrapError(dir, err)
+	}
+	var result []fileloaders.Entry
+	seen := make(map[string]bool)
+	for _, m := range hrefPattern.FindAllSubmatch(body, -1) {
+		href := html.UnescapeString(string(m[1]))
+		if strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+			continue
+		}
+		ref, err := dir.Parse(href)
+		if err != nil {
+			continue
+		}
+		name, ok := child(dir, ref)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, entry(dir, name))
+	}
+	return result, nil
+}
+
+func (l *Loader) listManifest(ctx context.Context, dir *url.URL) ([]fileloaders.Entry, error) {
+	u, err := dir.Parse(l.manifest)
+	if err != nil {
+		return nil, err
+	}
+	res, err := l.send(ctx, http.MethodGet, u, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	var doc any
+	if err = json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return nil, wrapError(u, err)
+	}
+	if l.schema.Entries != "" {
+		for _, key := range strings.Split(l.schema.Entries, ".") {
+			obj, ok := doc.(map[string]any)
+			if !ok {
+				return nil, wrapError(u, errors.New("manifest has no "+l.schema.Entries))
+			}
+			doc = obj[key]
+		}
+	}
+	items, ok := doc.([]any)
+	if !ok {
+		return nil, wrapError(u, errors.New("manifest entries are not an array"))
+	}
+	result := make([]fileloaders.Entry, 0, len(items))
+	for _, v := range items {
+		switch item := v.(type) {
+		case string:
+			if item != "" {
+				result = append(result, entry(dir, item))
+			}
+		case map[string]any:
+			if e, ok := l.schema.entry(dir, item); ok {
+				result = append(result, e)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s ManifestSchema) entry(dir *url.URL, item map[string]any) (fileloaders.Entry, bool) {
+	name, _ := item[s.Name].(string)
+	if name == "" {
+		return fileloaders.Entry{}, false
+	}
+	if isDir, _ := item[s.Dir].(bool); isDir && !strings.HasSuffix(name, "/") {
+		name += "/"
+	}
+	e := entry(dir, name)
+	switch v := item[s.Size].(type) {
+	case float64:
+		e.Size = int64(v)
+	case string:
+		e.Size, _ = strconv.ParseInt(v, 10, 64)
+	}
+	switch v := item[s.ModTime].(type) {
+	case string:
+		e.ModTime, _ = time.Parse(time.RFC3339, v)
+	case float64:
+		e.ModTime = time.Unix(int64(v), 0)
+	}
+	e.Hash, _ = item[s.Hash].(string)
+	return e, true
+}
+
+const propfind = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/><getetag/></prop></propfind>`
+
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ContentLength string `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ETag          string `xml:"getetag"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+func (l *Loader) listWebDAV(ctx context.Context, dir *url.URL) ([]fileloaders.Entry, error) {
+	header := make(http.Header)
+	header.Set("Depth", "1")
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+	res, err := l.send(ctx, "PROPFIND", dir, strings.NewReader(propfind), header)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	var ms multistatus
+	if err = xml.NewDecoder(res.Body).Decode(&ms); err != nil {
+		return nil, wrapError(dir, err)
+	}
+	result := make([]fileloaders.Entry, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		ref, err := dir.Parse(strings.TrimSpace(r.Href))
+		if err != nil {
+			continue
+		}
+		name, ok := child(dir, ref)
+		if !ok {
+			continue
+		}
+		for _, p := range r.Propstat {
+			if p.Status != "" && !strings.Contains(p.Status, " 200 ") {
+				continue
+			}
+			if p.Prop.ResourceType.Collection != nil && !strings.HasSuffix(name, "/") {
+				name += "/"
+			}
+			e := entry(dir, name)
+			if !e.IsDir() {
+				e.Size, _ = strconv.ParseInt(p.Prop.ContentLength, 10, 64)
+			}
+			e.ModTime, _ = http.ParseTime(p.Prop.LastModified)
+			e.Hash = p.Prop.ETag
+			result = append(result, e)
+			break
+		}
+	}
+	return result, nil
+}
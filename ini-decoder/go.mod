@@ -0,0 +1,10 @@
+module github.com/goccha/fileloaders/ini-decoder
+
+go 1.21
+
+require (
+	github.com/goccha/fileloaders v0.0.1-alpha.7
+	gopkg.in/ini.v1 v1.67.3
+)
+
+replace github.com/goccha/fileloaders => ../
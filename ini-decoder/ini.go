@@ -0,0 +1,40 @@
+package inidecoder
+
+import (
+	"encoding/json"
+
+	"github.com/goccha/fileloaders"
+	"gopkg.in/ini.v1"
+)
+
+// Decode parses INI data. Maps receive the keys of the default section at the top level
+// and one nested map per section; any other value is mapped with ini.File.MapTo.
+func Decode(data []byte, v any) error {
+	cfg, err := ini.Load(data)
+	if err != nil {
+		return err
+	}
+	switch v.(type) {
+	case *map[string]any, *any:
+		values := make(map[string]any)
+		for _, section := range cfg.Sections() {
+			if section.Name() == ini.DefaultSection {
+				for _, key := range section.Keys() {
+					values[key.Name()] = key.Value()
+				}
+				continue
+			}
+			values[section.Name()] = section.KeysHash()
+		}
+		bin, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(bin, v)
+	}
+	return cfg.MapTo(v)
+}
+
+func Register() {
+	fileloaders.RegisterDecoder(".ini", Decode)
+}
@@ -3,42 +3,42 @@ package fileloaders
 import (
 	"context"
 	"errors"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 )
 
-var ErrNotSupported = errors.New("does not supported")
-
 type LoaderFunc func(ctx context.Context, path string) (*File, error)
 type ListFunc func(ctx context.Context, path string) ([]string, error)
 
 type Option func(m map[string]Loader)
 
+// Setup applies options to the Default MapLoader.
 func Setup(options ...Option) {
-	if root == nil {
-		root = New(options...)
-	} else {
-		for _, option := range options {
-			option(root.loaders)
-		}
-	}
+	root.apply(options...)
 }
 
 func New(options ...Option) *MapLoader {
 	loader := &MapLoader{
 		loaders: make(map[string]Loader),
 	}
-	for _, option := range options {
-		option(loader.loaders)
-	}
+	loader.apply(options...)
 	return loader
 }
 
-var root *MapLoader
+var root = New()
 
 func Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
-	if root != nil {
-		if v, err := root.Load(ctx, path, opt...); err != nil {
+	return loadOrFile(ctx, FromContext(ctx), path, opt...)
+}
+
+// loadOrFile loads path with m and falls back to the local file system when m does not support it.
+func loadOrFile(ctx context.Context, m *MapLoader, path string, opt ...LoaderOption) (*File, error) {
+	if m != nil {
+		if v, err := m.Load(ctx, path, opt...); err != nil {
 			if !errors.Is(err, ErrNotSupported) {
 				return nil, err
 			}
@@ -46,12 +46,90 @@ func Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error)
 			return v, nil
 		}
 	}
-	return LoadFile(ctx, path)
+	return LoadFile(ctx, path, opt...)
 }
 
 func List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
-	if root != nil {
-		if v, err := root.List(ctx, path, opt...); err != nil {
+	return listOrFile(ctx, FromContext(ctx), path, opt...)
+}
+
+func listOrFile(ctx context.Context, m *MapLoader, path string, opt ...LoaderOption) ([]string, error) {
+	if m != nil {
+		if v, err := m.List(ctx, path, opt...); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				return nil, err
+			}
+		} else {
+			return v, nil
+		}
+	}
+	return ListFile(ctx, path, opt...)
+}
+
+func ListEntries(ctx context.Context, path string, opt ...LoaderOption) ([]Entry, error) {
+	if m := FromContext(ctx); m != nil {
+		if v, err := m.ListEntries(ctx, path, opt...); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				return nil, err
+			}
+		} else {
+			return v, nil
+		}
+	}
+	return ListFileEntries(ctx, path, opt...)
+}
+
+func Walk(ctx context.Context, path string, fn WalkFunc, opt ...LoaderOption) error {
+	if m := FromContext(ctx); m != nil {
+		if err := m.Walk(ctx, path, fn, opt...); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				return err
+			}
+		} else {
+			return nil
+		}
+	}
+	return WalkFile(ctx, path, fn, opt...)
+}
+
+func Open(ctx context.Context, path string, opt ...LoaderOption) (io.ReadCloser, *File, error) {
+	if m := FromContext(ctx); m != nil {
+		if r, v, err := m.Open(ctx, path, opt...); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				return nil, nil, err
+			}
+		} else {
+			return r, v, nil
+		}
+	}
+	return OpenFile(ctx, path, opt...)
+}
+
+func Save(ctx context.Context, path string, body io.Reader, opt ...LoaderOption) (*File, error) {
+	if m := FromContext(ctx); m != nil {
+		if v, err := m.Save(ctx, path, body, opt...); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				return nil, err
+			}
+		} else {
+			return v, nil
+		}
+	}
+	if !isLocal(path) {
+		return nil, ErrNotSupported
+	}
+	return SaveFile(ctx, path, body)
+}
+
+// isLocal reports whether path has no scheme or the "file" scheme, so that it may fall back to the local file system.
+func isLocal(path string) bool {
+	index := strings.Index(path, "://")
+	return index <= 0 || path[:index] == "file"
+}
+
+func Stat(ctx context.Context, path string, opt ...LoaderOption) (*FileInfo, error) {
+	if m := FromContext(ctx); m != nil {
+		if v, err := m.Stat(ctx, path, opt...); err != nil {
 			if !errors.Is(err, ErrNotSupported) {
 				return nil, err
 			}
@@ -59,14 +137,47 @@ func List(ctx context.Context, path string, opt ...LoaderOption) ([]string, erro
 			return v, nil
 		}
 	}
-	return ListFile(ctx, path)
+	if !isLocal(path) {
+		return nil, ErrNotSupported
+	}
+	return StatFile(ctx, path)
+}
+
+func Delete(ctx context.Context, path string, opt ...LoaderOption) error {
+	if m := FromContext(ctx); m != nil {
+		if err := m.Delete(ctx, path, opt...); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				return err
+			}
+		} else {
+			return nil
+		}
+	}
+	if !isLocal(path) {
+		return ErrNotSupported
+	}
+	return DeleteFile(ctx, path)
 }
 
-func LoadFile(ctx context.Context, path string) (*File, error) {
+func LoadFile(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+	if NewOptions(opt...).Range != nil {
+		r, file, err := OpenFile(ctx, path, opt...)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = r.Close()
+		}()
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return nil, NewLoadError("file", file.Path, nil, err)
+		}
+		return file.WriteBody(body), nil
+	}
 	path = strings.TrimPrefix(path, "file://")
 	bin, err := os.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return nil, NewLoadError("file", path, nil, err)
 	}
 	return &File{
 		Type: "file",
@@ -75,17 +186,159 @@ func LoadFile(ctx context.Context, path string) (*File, error) {
 	}, nil
 }
 
-func ListFile(ctx context.Context, path string) ([]string, error) {
+func OpenFile(ctx context.Context, path string, opt ...LoaderOption) (io.ReadCloser, *File, error) {
+	path = strings.TrimPrefix(path, "file://")
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, NewLoadError("file", path, nil, err)
+	}
+	file := &File{
+		Type: "file",
+		Path: path,
+	}
+	if rng := NewOptions(opt...).Range; rng != nil {
+		r, offset, err := rng.seek(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, NewLoadError("file", path, nil, err)
+		}
+		return r, file.Add(WithOffset(offset)), nil
+	}
+	return f, file, nil
+}
+
+func SaveFile(ctx context.Context, path string, body io.Reader) (*File, error) {
+	path = strings.TrimPrefix(path, "file://")
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, NewLoadError("file", path, nil, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return nil, NewLoadError("file", path, nil, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return nil, NewLoadError("file", path, nil, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return nil, NewLoadError("file", path, nil, err)
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return nil, NewLoadError("file", path, nil, err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return nil, NewLoadError("file", path, nil, err)
+	}
+	return &File{
+		Type: "file",
+		Path: path,
+	}, nil
+}
+
+func StatFile(ctx context.Context, path string) (*FileInfo, error) {
+	path = strings.TrimPrefix(path, "file://")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, NewLoadError("file", path, nil, err)
+	}
+	return &FileInfo{
+		Type:    "file",
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}, nil
+}
+
+func DeleteFile(ctx context.Context, path string) error {
 	path = strings.TrimPrefix(path, "file://")
-	entries, err := os.ReadDir(path)
+	return NewLoadError("file", path, nil, os.Remove(path))
+}
+
+func ListFile(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
+	entries, err := ListFileEntries(ctx, path, opt...)
 	if err != nil {
 		return nil, err
 	}
-	result := make([]string, len(entries))
-	for i, v := range entries {
-		result[i] = v.Name()
+	return EntryNames(entries), nil
+}
+
+func ListFileEntries(ctx context.Context, path string, opt ...LoaderOption) ([]Entry, error) {
+	return CollectEntries(func(fn WalkFunc) error {
+		return WalkFile(ctx, path, fn, opt...)
+	})
+}
+
+func WalkFile(ctx context.Context, path string, fn WalkFunc, opt ...LoaderOption) error {
+	scheme := ""
+	if strings.HasPrefix(path, "file://") {
+		scheme = "file://"
+		path = strings.TrimPrefix(path, scheme)
+	}
+	if !NewOptions(opt...).Recursive {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return NewLoadError("file", path, nil, err)
+		}
+		for _, v := range entries {
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+			if err = fn(fileEntry(scheme, path, v.Name(), v)); err != nil {
+				if errors.Is(err, SkipAll) {
+					return nil
+				}
+				return err
+			}
+		}
+		return nil
 	}
-	return result, nil
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return NewLoadError("file", p, nil, err)
+		}
+		if p == path {
+			return nil
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		name, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		return fn(fileEntry(scheme, path, filepath.ToSlash(name), d))
+	})
+	if errors.Is(err, SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func fileEntry(scheme, dir, name string, d fs.DirEntry) Entry {
+	entry := Entry{
+		Name: name,
+		URI:  scheme + strings.TrimSuffix(dir, "/") + "/" + name,
+		Kind: KindFile,
+	}
+	if d.IsDir() {
+		entry.Kind = KindDir
+	}
+	if info, err := d.Info(); err == nil {
+		entry.ModTime = info.ModTime()
+		if !d.IsDir() {
+			entry.Size = info.Size()
+		}
+	}
+	return entry
 }
 
 type LoaderOption func(loader Loader)
@@ -95,20 +348,77 @@ type Loader interface {
 	List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error)
 }
 
+// EntryLister is implemented by loaders that can list files together with their metadata.
+// Loaders that do not implement it are listed through List and only report entry names.
+type EntryLister interface {
+	ListEntries(ctx context.Context, path string, opt ...LoaderOption) ([]Entry, error)
+}
+
+// WalkFunc is called for each entry of a listing. Returning SkipAll stops the walk without an error.
+type WalkFunc func(entry Entry) error
+
+var SkipAll = fs.SkipAll
+
+// Walker is implemented by loaders that can stream a listing page by page.
+type Walker interface {
+	Walk(ctx context.Context, path string, fn WalkFunc, opt ...LoaderOption) error
+}
+
+// CollectEntries runs walk and gathers every entry passed to its WalkFunc.
+func CollectEntries(walk func(fn WalkFunc) error) ([]Entry, error) {
+	var result []Entry
+	if err := walk(func(entry Entry) error {
+		result = append(result, entry)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Opener is implemented by loaders that can stream a file without buffering it in memory.
+// Loaders that do not implement it are opened through Load.
+type Opener interface {
+	Open(ctx context.Context, path string, opt ...LoaderOption) (io.ReadCloser, *File, error)
+}
+
+// Saver is implemented by loaders that can write a file back to their backend.
+type Saver interface {
+	Save(ctx context.Context, path string, body io.Reader, opt ...LoaderOption) (*File, error)
+}
+
+// Stater is implemented by loaders that can read file metadata without downloading the body.
+type Stater interface {
+	Stat(ctx context.Context, path string, opt ...LoaderOption) (*FileInfo, error)
+}
+
+// Deleter is implemented by loaders that can remove a file from their backend.
+type Deleter interface {
+	Delete(ctx context.Context, path string, opt ...LoaderOption) error
+}
+
+// MapLoader dispatches every call to the Loader registered for the scheme of the path.
+// It is safe for concurrent use.
 type MapLoader struct {
+	mu      sync.RWMutex
 	loaders map[string]Loader
 }
 
-func (m *MapLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+func (m *MapLoader) lookup(path string) (Loader, string, bool) {
 	index := strings.Index(path, "://")
 	var prefix string
 	if index > 0 {
 		prefix = path[:index]
 	}
-	loader, ok := m.loaders[prefix]
+	loader, ok := m.Lookup(prefix)
+	return loader, prefix, ok
+}
+
+func (m *MapLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+	loader, prefix, ok := m.lookup(path)
 	if !ok {
 		if prefix == "file" {
-			return LoadFile(ctx, path)
+			return LoadFile(ctx, path, opt...)
 		}
 		return nil, ErrNotSupported
 	}
@@ -116,17 +426,154 @@ func (m *MapLoader) Load(ctx context.Context, path string, opt ...LoaderOption)
 }
 
 func (m *MapLoader) List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
-	index := strings.Index(path, "://")
-	var prefix string
-	if index > 0 {
-		prefix = path[:index]
-	}
-	loader, ok := m.loaders[prefix]
+	loader, prefix, ok := m.lookup(path)
 	if !ok {
 		if prefix == "file" {
-			return ListFile(ctx, path)
+			return ListFile(ctx, path, opt...)
 		}
 		return nil, ErrNotSupported
 	}
 	return loader.List(ctx, path, opt...)
 }
+
+func (m *MapLoader) Open(ctx context.Context, path string, opt ...LoaderOption) (io.ReadCloser, *File, error) {
+	loader, prefix, ok := m.lookup(path)
+	if !ok {
+		if prefix == "file" {
+			return OpenFile(ctx, path, opt...)
+		}
+		return nil, nil, ErrNotSupported
+	}
+	return open(ctx, loader, path, opt...)
+}
+
+func open(ctx context.Context, loader Loader, path string, opt ...LoaderOption) (io.ReadCloser, *File, error) {
+	if v, ok := loader.(Opener); ok {
+		return v.Open(ctx, path, opt...)
+	}
+	file, err := loader.Load(ctx, path, opt...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return io.NopCloser(file.Reader()), file, nil
+}
+
+func (m *MapLoader) Save(ctx context.Context, path string, body io.Reader, opt ...LoaderOption) (*File, error) {
+	loader, prefix, ok := m.lookup(path)
+	if !ok {
+		if prefix == "file" {
+			return SaveFile(ctx, path, body)
+		}
+		return nil, ErrNotSupported
+	}
+	return save(ctx, loader, path, body, opt...)
+}
+
+func save(ctx context.Context, loader Loader, path string, body io.Reader, opt ...LoaderOption) (*File, error) {
+	if v, ok := loader.(Saver); ok {
+		return v.Save(ctx, path, body, opt...)
+	}
+	return nil, ErrNotSupported
+}
+
+func (m *MapLoader) Stat(ctx context.Context, path string, opt ...LoaderOption) (*FileInfo, error) {
+	loader, prefix, ok := m.lookup(path)
+	if !ok {
+		if prefix == "file" {
+			return StatFile(ctx, path)
+		}
+		return nil, ErrNotSupported
+	}
+	return stat(ctx, loader, path, opt...)
+}
+
+func stat(ctx context.Context, loader Loader, path string, opt ...LoaderOption) (*FileInfo, error) {
+	if v, ok := loader.(Stater); ok {
+		return v.Stat(ctx, path, opt...)
+	}
+	return nil, ErrNotSupported
+}
+
+func (m *MapLoader) Delete(ctx context.Context, path string, opt ...LoaderOption) error {
+	loader, prefix, ok := m.lookup(path)
+	if !ok {
+		if prefix == "file" {
+			return DeleteFile(ctx, path)
+		}
+		return ErrNotSupported
+	}
+	return remove(ctx, loader, path, opt...)
+}
+
+func remove(ctx context.Context, loader Loader, path string, opt ...LoaderOption) error {
+	if v, ok := loader.(Deleter); ok {
+		return v.Delete(ctx, path, opt...)
+	}
+	return ErrNotSupported
+}
+
+func (m *MapLoader) ListEntries(ctx context.Context, path string, opt ...LoaderOption) ([]Entry, error) {
+	loader, prefix, ok := m.lookup(path)
+	if !ok {
+		if prefix == "file" {
+			return ListFileEntries(ctx, path, opt...)
+		}
+		return nil, ErrNotSupported
+	}
+	return listEntries(ctx, loader, path, opt...)
+}
+
+func listEntries(ctx context.Context, loader Loader, path string, opt ...LoaderOption) ([]Entry, error) {
+	if v, ok := loader.(EntryLister); ok {
+		return v.ListEntries(ctx, path, opt...)
+	}
+	names, err := loader.List(ctx, path, opt...)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Entry, len(names))
+	for i, v := range names {
+		result[i] = Entry{Name: v}
+	}
+	return result, nil
+}
+
+func (m *MapLoader) Walk(ctx context.Context, path string, fn WalkFunc, opt ...LoaderOption) error {
+	loader, prefix, ok := m.lookup(path)
+	if !ok {
+		if prefix == "file" {
+			return WalkFile(ctx, path, fn, opt...)
+		}
+		return ErrNotSupported
+	}
+	return walk(ctx, loader, path, fn, opt...)
+}
+
+func walk(ctx context.Context, loader Loader, path string, fn WalkFunc, opt ...LoaderOption) error {
+	if v, ok := loader.(Walker); ok {
+		if err := v.Walk(ctx, path, fn, opt...); err != nil && !errors.Is(err, SkipAll) {
+			return err
+		}
+		return nil
+	}
+	entries, err := listEntries(ctx, loader, path, opt...)
+	if err != nil {
+		return err
+	}
+	return walkEntries(ctx, entries, fn)
+}
+
+func walkEntries(ctx context.Context, entries []Entry, fn WalkFunc) error {
+	for _, v := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			if errors.Is(err, SkipAll) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package fileloaders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type ListStrategy int
+
+const (
+	// ListReplace replaces a list with the one from the later source.
+	ListReplace ListStrategy = iota
+	// ListAppend appends the elements of the later source.
+	ListAppend
+	// ListMergeByKey deep-merges list elements that are maps with the same value for the merge key
+	// and appends the others.
+	ListMergeByKey
+)
+
+type MergeOption func(m *Merger)
+
+func WithListStrategy(strategy ListStrategy) MergeOption {
+	return func(m *Merger) {
+		m.listStrategy = strategy
+	}
+}
+
+// WithMergeKey selects ListMergeByKey using key to identify list elements.
+func WithMergeKey(key string) MergeOption {
+	return func(m *Merger) {
+		m.listStrategy = ListMergeByKey
+		m.mergeKey = key
+	}
+}
+
+type Merger struct {
+	listStrategy ListStrategy
+	mergeKey     string
+}
+
+func NewMerger(opts ...MergeOption) *Merger {
+	m := &Merger{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Merged is a document built from several sources. Sources maps each leaf, written as "a.b[0].c",
+// to the URI it was taken from.
+type Merged struct {
+	Data    map[string]any
+	Sources map[string]string
+}
+
+func (m *Merged) Unmarshal(obj any) error {
+	bin, err := json.Marshal(m.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bin, obj)
+}
+
+func (m *Merged) Source(key string) (string, bool) {
+	v, ok := m.Sources[key]
+	return v, ok
+}
+
+// LoadMerged loads every path in order and deep-merges them, later sources taking precedence.
+func LoadMerged(ctx context.Context, paths ...string) (*Merged, error) {
+	return NewMerger().Load(ctx, paths...)
+}
+
+func (m *Merger) Load(ctx context.Context, paths ...string) (*Merged, error) {
+	merged := &Merged{
+		Data:    make(map[string]any),
+		Sources: make(map[string]string),
+	}
+	for _, path := range paths {
+		file, err := Load(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		var doc map[string]any
+		if err = file.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		m.Merge(merged, doc, path)
+	}
+	return merged, nil
+}
+
+func (m *Merger) Merge(dst *Merged, src map[string]any, source string) {
+	if dst.Data == nil {
+		dst.Data = make(map[string]any)
+	}
+	if dst.Sources == nil {
+		dst.Sources = make(map[string]string)
+	}
+	dst.Data = m.merge(dst, "", dst.Data, normalize(src), source).(map[string]any)
+}
+
+func (m *Merger) merge(dst *Merged, key string, current, value any, source string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		d, ok := current.(map[string]any)
+		if !ok {
+			dst.clear(key)
+			d = make(map[string]any, len(v))
+		}
+		for k, child := range v {
+			d[k] = m.merge(dst, joinKey(key, k), d[k], child, source)
+		}
+		return d
+	case []any:
+		d, ok := current.([]any)
+		if !ok || m.listStrategy == ListReplace {
+			dst.clear(key)
+			dst.record(key, v, source)
+			return v
+		}
+		for _, child := range v {
+			if index := m.indexOf(d, child); index >= 0 {
+				d[index] = m.merge(dst, indexKey(key, index), d[index], child, source)
+				continue
+			}
+			dst.record(indexKey(key, len(d)), child, source)
+			d = append(d, child)
+		}
+		return d
+	}
+	dst.clear(key)
+	dst.Sources[key] = source
+	return value
+}
+
+func (m *Merger) indexOf(list []any, value any) int {
+	if m.listStrategy != ListMergeByKey {
+		return -1
+	}
+	v, ok := value.(map[string]any)
+	if !ok {
+		return -1
+	}
+	id, ok := v[m.mergeKey]
+	if !ok {
+		return -1
+	}
+	for i, item := range list {
+		if item, ok := item.(map[string]any); ok && fmt.Sprint(item[m.mergeKey]) == fmt.Sprint(id) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Merged) clear(key string) {
+	if key == "" {
+		return
+	}
+	for k := range m.Sources {
+		if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(k, key+"[") {
+			delete(m.Sources, k)
+		}
+	}
+}
+
+func (m *Merged) record(key string, value any, source string) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) > 0 {
+			for k, child := range v {
+				m.record(joinKey(key, k), child, source)
+			}
+			return
+		}
+	case []any:
+		if len(v) > 0 {
+			for i, child := range v {
+				m.record(indexKey(key, i), child, source)
+			}
+			return
+		}
+	}
+	m.Sources[key] = source
+}
+
+func joinKey(key, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
+
+func indexKey(key string, index int) string {
+	return key + "[" + strconv.Itoa(index) + "]"
+}
+
+// normalize converts the map[any]any values some decoders produce into map[string]any.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = normalize(child)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, child := range v {
+			m[fmt.Sprint(k)] = normalize(child)
+		}
+		return m
+	case []any:
+		for i, child := range v {
+			v[i] = normalize(child)
+		}
+		return v
+	}
+	return value
+}
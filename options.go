@@ -0,0 +1,113 @@
+package fileloaders
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Options holds the LoaderOption settings shared by every loader.
+// Loaders read them with NewOptions; the loader specific options keep type-asserting their own Loader.
+type Options struct {
+	Recursive bool
+	Previous  *File
+	Range     *Range
+	applied   int
+	custom    bool
+}
+
+func (o *Options) options() *Options {
+	return o
+}
+
+type optionHolder interface {
+	options() *Options
+}
+
+func NewOptions(opt ...LoaderOption) *Options {
+	holder := &optionLoader{}
+	for _, v := range opt {
+		applied := holder.applied
+		v(holder)
+		if holder.applied == applied {
+			holder.custom = true
+		}
+	}
+	return &holder.Options
+}
+
+func setOption(l Loader, fn func(o *Options)) {
+	if v, ok := l.(optionHolder); ok {
+		o := v.options()
+		fn(o)
+		o.applied++
+	}
+}
+
+// Comparable reports whether every option was one of the shared options above, so that Key describes them all.
+// Loader specific options, such as the headers of the HTTP loader, cannot be compared.
+func (o *Options) Comparable() bool {
+	return !o.custom
+}
+
+// Key describes the options by value: the recursive flag, the ETag, version and modification time of Previous, and the range.
+func (o *Options) Key() string {
+	var parts []string
+	if o.Recursive {
+		parts = append(parts, "recursive")
+	}
+	if o.Previous != nil {
+		etag, _ := o.Previous.ETag()
+		version, _ := o.Previous.Version()
+		var modTime int64
+		if t, ok := o.Previous.ModTime(); ok {
+			modTime = t.UnixNano()
+		}
+		parts = append(parts, "previous="+etag+"|"+version+"|"+strconv.FormatInt(modTime, 10))
+	}
+	if o.Range != nil {
+		parts = append(parts, "range="+o.Range.Header())
+	}
+	return strings.Join(parts, "&")
+}
+
+// WithRecursive lists every entry below the path instead of a single directory level.
+func WithRecursive(recursive bool) LoaderOption {
+	return func(l Loader) {
+		setOption(l, func(o *Options) {
+			o.Recursive = recursive
+		})
+	}
+}
+
+// IfChanged makes a conditional request against the validators (hash, version, ETag) of prev.
+// Loaders that support it return ErrNotModified when the file has not changed; the others load it again.
+func IfChanged(prev *File) LoaderOption {
+	return func(l Loader) {
+		setOption(l, func(o *Options) {
+			o.Previous = prev
+		})
+	}
+}
+
+// WithRange reads only length bytes starting at offset. A length of zero or less reads to the end
+// and a negative offset reads the last -offset bytes. The returned File reports the offset it starts at.
+func WithRange(offset, length int64) LoaderOption {
+	return func(l Loader) {
+		setOption(l, func(o *Options) {
+			o.Range = &Range{Offset: offset, Length: length}
+		})
+	}
+}
+
+type optionLoader struct {
+	Options
+}
+
+func (l *optionLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+	return nil, ErrNotSupported
+}
+
+func (l *optionLoader) List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
+	return nil, ErrNotSupported
+}
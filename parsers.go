@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type FileOption func(f *File)
@@ -21,13 +23,110 @@ func WithVersion(version *string) FileOption {
 	}
 }
 
+func WithContentType(contentType *string) FileOption {
+	return func(f *File) {
+		f.contentType = contentType
+	}
+}
+
+// WithETag records the entity tag of the backend response when it differs from the hash.
+func WithETag(etag *string) FileOption {
+	return func(f *File) {
+		f.etag = etag
+	}
+}
+
+// WithModTime records the modification time reported by the backend, such as the Last-Modified header.
+func WithModTime(t time.Time) FileOption {
+	return func(f *File) {
+		f.modTime = t
+	}
+}
+
+// WithStale marks a file served from a cache because loading it failed with err.
+func WithStale(err error) FileOption {
+	return func(f *File) {
+		f.staleErr = err
+	}
+}
+
+// WithSource records the URI a file was actually loaded from, such as the candidate chosen by LoadFirst.
+func WithSource(uri string) FileOption {
+	return func(f *File) {
+		f.source = uri
+	}
+}
+
+// WithOffset records the position in the whole file of the first byte of a ranged read.
+func WithOffset(offset int64) FileOption {
+	return func(f *File) {
+		f.offset = offset
+	}
+}
+
 type File struct {
+	Type        string
+	Bucket      string
+	Path        string
+	body        []byte
+	hash        *string
+	version     *string
+	contentType *string
+	etag        *string
+	modTime     time.Time
+	staleErr    error
+	source      string
+	offset      int64
+	uri         *URI
+}
+
+type FileInfo struct {
 	Type    string
 	Bucket  string
 	Path    string
-	body    []byte
-	hash    *string
-	version *string
+	Size    int64
+	ModTime time.Time
+	Hash    string
+	Version string
+	IsDir   bool
+}
+
+type EntryKind int
+
+const (
+	KindFile EntryKind = iota
+	KindDir
+)
+
+func (k EntryKind) String() string {
+	if k == KindDir {
+		return "dir"
+	}
+	return "file"
+}
+
+// Entry is a single result of ListEntries. Name is the value reported by List: the path relative to the
+// listed directory, "/" separated and without a trailing "/" for directories, on every backend. URI can be passed to Load.
+type Entry struct {
+	Name    string
+	URI     string
+	Size    int64
+	ModTime time.Time
+	Hash    string
+	Version string
+	Kind    EntryKind
+}
+
+func (e Entry) IsDir() bool {
+	return e.Kind == KindDir
+}
+
+func EntryNames(entries []Entry) []string {
+	result := make([]string, len(entries))
+	for i, v := range entries {
+		result[i] = v.Name
+	}
+	return result
 }
 
 func (f *File) Hash() (string, bool) {
@@ -44,6 +143,58 @@ func (f *File) Version() (string, bool) {
 	return *f.version, true
 }
 
+// ETag returns the entity tag for conditional requests, which is the hash unless WithETag was set.
+func (f *File) ETag() (string, bool) {
+	if f.etag != nil && *f.etag != "" {
+		return *f.etag, true
+	}
+	return f.Hash()
+}
+
+func (f *File) ModTime() (time.Time, bool) {
+	return f.modTime, !f.modTime.IsZero()
+}
+
+func (f *File) ContentType() (string, bool) {
+	if f.contentType == nil || *f.contentType == "" {
+		return "", false
+	}
+	return *f.contentType, true
+}
+
+// URI returns the parsed path of the file. Files that were not created by Parse get a URI without query.
+func (f *File) URI() *URI {
+	if f.uri != nil {
+		return f.uri
+	}
+	return &URI{
+		Scheme: f.Type,
+		Bucket: f.Bucket,
+		Key:    f.Path,
+		Query:  url.Values{},
+	}
+}
+
+// Source returns the URI recorded by WithSource.
+func (f *File) Source() (string, bool) {
+	return f.source, f.source != ""
+}
+
+// Offset returns the position of the body in the whole file, which is not zero for ranged reads.
+func (f *File) Offset() int64 {
+	return f.offset
+}
+
+// Stale reports whether the file is an old cached copy returned because the backend failed.
+func (f *File) Stale() bool {
+	return f.staleErr != nil
+}
+
+// StaleError returns the backend error that caused a stale copy to be returned.
+func (f *File) StaleError() error {
+	return f.staleErr
+}
+
 func (f *File) GetBody() []byte {
 	return f.body
 }
@@ -55,6 +206,15 @@ func (f *File) Add(o ...FileOption) *File {
 	return f
 }
 
+func (f *File) clone() *File {
+	c := *f
+	if f.body != nil {
+		c.body = make([]byte, len(f.body))
+		copy(c.body, f.body)
+	}
+	return &c
+}
+
 func (f *File) WriteBody(p []byte) *File {
 	f.body = p
 	return f
@@ -78,13 +238,26 @@ func (f *File) Unmarshal(obj any) error {
 	return json.Unmarshal(f.body, obj)
 }
 
+// Parse splits a "scheme://bucket/key?query" path into a File. The parsed URI is available with File.URI.
+// The key is percent-decoded, so "?" and "%" in a key are written "%3F" and "%25", as URI.String does;
+// a key that is not validly escaped, such as "50%off.txt", is kept as written. A "#" is part of the key.
 func Parse(path string) *File {
 	index := strings.Index(path, "://")
 	if index < 0 {
 		return nil
 	}
+	if u, err := ParseURI(strings.ReplaceAll(path, "#", "%23")); err == nil {
+		return &File{
+			Type:   u.Scheme,
+			Bucket: u.Bucket,
+			Path:   u.Key,
+			uri:    u,
+		}
+	}
+	// Keys that are not valid URIs, such as "50%off.txt", are split by hand.
 	prefix := path[:index]
-	path = path[index+3:]
+	path, rawQuery, _ := strings.Cut(path[index+3:], "?")
+	query, _ := url.ParseQuery(rawQuery)
 	index = strings.Index(path, "/")
 	var bucket string
 	if index >= 0 {
@@ -95,5 +268,11 @@ func Parse(path string) *File {
 		Type:   prefix,
 		Bucket: bucket,
 		Path:   path,
+		uri: &URI{
+			Scheme: prefix,
+			Bucket: bucket,
+			Key:    path,
+			Query:  query,
+		},
 	}
 }
@@ -0,0 +1,174 @@
+package fileloaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Range is the part of a file selected by WithRange.
+type Range struct {
+	Offset int64
+	Length int64
+}
+
+// Header returns the value of the HTTP Range header selecting r, which S3 accepts as well.
+func (r *Range) Header() string {
+	switch {
+	case r.Offset < 0:
+		return "bytes=" + strconv.FormatInt(r.Offset, 10)
+	case r.Length <= 0:
+		return "bytes=" + strconv.FormatInt(r.Offset, 10) + "-"
+	}
+	return "bytes=" + strconv.FormatInt(r.Offset, 10) + "-" + strconv.FormatInt(r.Offset+r.Length-1, 10)
+}
+
+// ParseContentRange parses a "bytes start-end/size" Content-Range header. size is -1 when it is "*".
+func ParseContentRange(v string) (start, end, size int64, ok bool) {
+	v, found := strings.CutPrefix(strings.TrimSpace(v), "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	span, total, found := strings.Cut(v, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	first, last, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+	var err error
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if end, err = strconv.ParseInt(last, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	size = -1
+	if total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return 0, 0, 0, false
+		}
+	}
+	return start, end, size, true
+}
+
+// seek moves f to the start of r and returns a reader limited to r together with the offset.
+func (r *Range) seek(f *os.File) (io.ReadCloser, int64, error) {
+	offset := r.Offset
+	if offset < 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		offset = max(info.Size()+offset, 0)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	if r.Offset < 0 || r.Length <= 0 {
+		return f, offset, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, r.Length), f}, offset, nil
+}
+
+type DownloadOption func(o *downloadOptions)
+
+type downloadOptions struct {
+	retryOptions  []RetryOption
+	loaderOptions []LoaderOption
+}
+
+// WithDownloadRetry configures the retries of Download. It makes 5 attempts by default.
+func WithDownloadRetry(opts ...RetryOption) DownloadOption {
+	return func(o *downloadOptions) {
+		o.retryOptions = append(o.retryOptions, opts...)
+	}
+}
+
+// WithDownloadLoaderOptions passes LoaderOptions to every attempt.
+func WithDownloadLoaderOptions(opt ...LoaderOption) DownloadOption {
+	return func(o *downloadOptions) {
+		o.loaderOptions = append(o.loaderOptions, opt...)
+	}
+}
+
+var errChanged = errors.New("file changed during download")
+
+// Download copies path to w and returns the number of bytes written. When reading the body fails,
+// the download is retried with WithRange from the last received byte. Loaders that ignore the
+// range are read again from the start, skipping what w already received. Download fails when the
+// ETag or the version, such as a GCS generation, of the file changes between attempts.
+func Download(ctx context.Context, path string, w io.Writer, opts ...DownloadOption) (int64, error) {
+	o := &downloadOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	r := NewRetryLoader(nil, append([]RetryOption{WithMaxAttempts(5)}, o.retryOptions...)...)
+	var written int64
+	var etag, version string
+	err := r.do(ctx, false, func(ctx context.Context) error {
+		opt := o.loaderOptions
+		if written > 0 {
+			opt = append(opt[:len(opt):len(opt)], WithRange(written, 0))
+		}
+		rc, file, err := Open(ctx, path, opt...)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = rc.Close()
+		}()
+		if changed(&etag, file.ETag) || changed(&version, file.Version) {
+			return NewLoadError(file.Type, file.Bucket+"/"+file.Path, nil, errChanged)
+		}
+		src := &downloadReader{r: rc}
+		skip := written - file.Offset()
+		if skip < 0 {
+			return NewLoadError(file.Type, file.Bucket+"/"+file.Path, nil, errChanged)
+		}
+		if _, err = io.CopyN(io.Discard, src, skip); err == nil {
+			var n int64
+			n, err = io.Copy(w, src)
+			written += n
+		}
+		if err != nil && errors.Is(err, src.err) {
+			return NewLoadError(file.Type, file.Bucket+"/"+file.Path, ErrTransient, err)
+		}
+		return err
+	})
+	return written, err
+}
+
+// changed keeps the first value reported by get in last and reports whether a later one differs.
+func changed(last *string, get func() (string, bool)) bool {
+	v, ok := get()
+	if !ok {
+		return false
+	}
+	if *last == "" {
+		*last = v
+		return false
+	}
+	return v != *last
+}
+
+// downloadReader records the read error, so that Download only retries failures of the source.
+type downloadReader struct {
+	r   io.Reader
+	err error
+}
+
+func (d *downloadReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err != nil && err != io.EOF {
+		d.err = err
+	}
+	return n, err
+}
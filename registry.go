@@ -0,0 +1,96 @@
+package fileloaders
+
+import (
+	"context"
+	"sort"
+)
+
+// Default returns the MapLoader used by the package level functions when the context carries none.
+func Default() *MapLoader {
+	return root
+}
+
+// Reset removes every loader from the Default MapLoader.
+func Reset() {
+	root.Reset()
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that makes the package level functions use m instead of Default.
+func NewContext(ctx context.Context, m *MapLoader) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the MapLoader carried by ctx, or Default.
+func FromContext(ctx context.Context) *MapLoader {
+	if m, ok := ctx.Value(contextKey{}).(*MapLoader); ok && m != nil {
+		return m
+	}
+	return root
+}
+
+// binder is implemented by loaders that load other URIs through the MapLoader they are registered in.
+// Loaders wrapped by CachingLoader, RetryLoader or DedupLoader are bound as well.
+type binder interface {
+	bind(m *MapLoader)
+}
+
+func (m *MapLoader) apply(options ...Option) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, option := range options {
+		option(m.loaders)
+	}
+	for _, v := range m.loaders {
+		for {
+			if b, ok := v.(binder); ok {
+				b.bind(m)
+			}
+			w, ok := v.(wrapper)
+			if !ok {
+				break
+			}
+			v = w.unwrap()
+		}
+	}
+}
+
+// Register sets the loader of scheme, replacing the previous one.
+func (m *MapLoader) Register(scheme string, loader Loader) {
+	m.apply(func(loaders map[string]Loader) {
+		loaders[scheme] = loader
+	})
+}
+
+func (m *MapLoader) Unregister(scheme string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.loaders, scheme)
+}
+
+// Schemes returns the registered schemes in sorted order.
+func (m *MapLoader) Schemes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]string, 0, len(m.loaders))
+	for k := range m.loaders {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (m *MapLoader) Lookup(scheme string) (Loader, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	loader, ok := m.loaders[scheme]
+	return loader, ok
+}
+
+// Reset removes every registered loader.
+func (m *MapLoader) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loaders = make(map[string]Loader)
+}
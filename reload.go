@@ -0,0 +1,144 @@
+package fileloaders
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ReloadOption[T any] func(r *Reloadable[T])
+
+// WithValidator rejects an update when fn returns an error; the last good value is kept.
+func WithValidator[T any](fn func(v *T) error) ReloadOption[T] {
+	return func(r *Reloadable[T]) {
+		r.validate = fn
+	}
+}
+
+// WithReloadWatch sets the WatchOption used to detect changes, such as WithInterval.
+func WithReloadWatch[T any](opt ...WatchOption) ReloadOption[T] {
+	return func(r *Reloadable[T]) {
+		r.watchOptions = append(r.watchOptions, opt...)
+	}
+}
+
+// WithReloadLoaderOptions passes LoaderOptions to every Load.
+func WithReloadLoaderOptions[T any](opt ...LoaderOption) ReloadOption[T] {
+	return func(r *Reloadable[T]) {
+		r.loaderOptions = append(r.loaderOptions, opt...)
+	}
+}
+
+// Reloadable holds the decoded content of a file and replaces it whenever Watch reports a change.
+type Reloadable[T any] struct {
+	path          string
+	value         atomic.Pointer[T]
+	validate      func(v *T) error
+	watchOptions  []WatchOption
+	loaderOptions []LoaderOption
+
+	mu         sync.Mutex
+	listeners  []func(old, new *T)
+	lastErr    error
+	lastReload time.Time
+}
+
+// NewReloadable loads and decodes path, then keeps it up to date until ctx is done.
+func NewReloadable[T any](ctx context.Context, path string, opts ...ReloadOption[T]) (*Reloadable[T], error) {
+	r := &Reloadable[T]{path: path}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	events, err := Watch(ctx, path, append(r.watchOptions, WithWatchLoaderOptions(r.loaderOptions...))...)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for event := range events {
+			switch event.Kind {
+			case EventChanged:
+				_ = r.apply(event.File)
+			case EventRemoved:
+				scheme := "file"
+				if f := Parse(path); f != nil {
+					scheme = f.Type
+				}
+				r.setError(NewLoadError(scheme, path, nil, ErrNotExist))
+			default:
+				r.setError(event.Err)
+			}
+		}
+	}()
+	return r, nil
+}
+
+func (r *Reloadable[T]) Get() *T {
+	return r.value.Load()
+}
+
+// OnChange registers fn to be called after every accepted update.
+func (r *Reloadable[T]) OnChange(fn func(old, new *T)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Reload loads the file immediately instead of waiting for the next change.
+func (r *Reloadable[T]) Reload(ctx context.Context) error {
+	file, err := Load(ctx, r.path, r.loaderOptions...)
+	if err != nil {
+		r.setError(err)
+		return err
+	}
+	return r.apply(file)
+}
+
+// LastError returns the error of the latest reload attempt, or nil when it succeeded.
+func (r *Reloadable[T]) LastError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastErr
+}
+
+// LastReload returns when a value was last accepted.
+func (r *Reloadable[T]) LastReload() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastReload
+}
+
+func (r *Reloadable[T]) apply(file *File) error {
+	v := new(T)
+	if err := file.Decode(v); err != nil {
+		r.setError(err)
+		return err
+	}
+	if r.validate != nil {
+		if err := r.validate(v); err != nil {
+			r.setError(err)
+			return err
+		}
+	}
+	old := r.value.Swap(v)
+	r.mu.Lock()
+	r.lastErr = nil
+	r.lastReload = time.Now()
+	listeners := r.listeners
+	r.mu.Unlock()
+	if old != nil {
+		for _, fn := range listeners {
+			fn(old, v)
+		}
+	}
+	return nil
+}
+
+func (r *Reloadable[T]) setError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastErr = err
+}
@@ -0,0 +1,264 @@
+package fileloaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// Middleware wraps a Loader. Wrappers should implement the optional interfaces of the loader they wrap,
+// as CachingLoader and RetryLoader do, or the MapLoader falls back to Load and List.
+type Middleware func(loader Loader) Loader
+
+// WithMiddleware wraps every loader registered before it with mw, the first one being the outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(m map[string]Loader) {
+		for k, v := range m {
+			m[k] = chain(v, mw...)
+		}
+	}
+}
+
+// Use wraps every loader currently registered in m with mw, the first one being the outermost.
+func (m *MapLoader) Use(mw ...Middleware) {
+	m.apply(WithMiddleware(mw...))
+}
+
+func chain(loader Loader, mw ...Middleware) Loader {
+	for i := len(mw) - 1; i >= 0; i-- {
+		loader = mw[i](loader)
+	}
+	return loader
+}
+
+type RetryOption func(r *RetryLoader)
+
+// WithMaxAttempts sets how many times an operation is tried, including the first attempt.
+func WithMaxAttempts(n int) RetryOption {
+	return func(r *RetryLoader) {
+		r.maxAttempts = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled on every further retry up to max.
+func WithBackoff(initial, max time.Duration) RetryOption {
+	return func(r *RetryLoader) {
+		r.initialDelay = initial
+		r.maxDelay = max
+	}
+}
+
+// WithRetryJitter randomly shortens every delay by up to the given fraction (0 to 1).
+func WithRetryJitter(fraction float64) RetryOption {
+	return func(r *RetryLoader) {
+		r.jitter = fraction
+	}
+}
+
+// WithAttemptTimeout bounds every single attempt. It is not applied to Open, whose body is read after it returns.
+func WithAttemptTimeout(timeout time.Duration) RetryOption {
+	return func(r *RetryLoader) {
+		r.attemptTimeout = timeout
+	}
+}
+
+// WithRetryable replaces IsRetryable as the classifier of retryable errors.
+func WithRetryable(fn func(err error) bool) RetryOption {
+	return func(r *RetryLoader) {
+		r.retryable = fn
+	}
+}
+
+// Retry returns a Middleware that wraps loaders with NewRetryLoader.
+func Retry(opts ...RetryOption) Middleware {
+	return func(loader Loader) Loader {
+		return NewRetryLoader(loader, opts...)
+	}
+}
+
+// RetryLoader retries failed operations with exponential backoff. A RetryAfter reported in a
+// LoadError is honored, and the error is returned when it is longer than the maximum delay.
+// Save is only retried when the body is an io.Seeker, and Walk is never retried.
+type RetryLoader struct {
+	loader         Loader
+	maxAttempts    int
+	initialDelay   time.Duration
+	maxDelay       time.Duration
+	jitter         float64
+	attemptTimeout time.Duration
+	retryable      func(err error) bool
+}
+
+func NewRetryLoader(loader Loader, opts ...RetryOption) *RetryLoader {
+	r := &RetryLoader{
+		loader:       loader,
+		maxAttempts:  3,
+		initialDelay: 100 * time.Millisecond,
+		maxDelay:     5 * time.Second,
+		jitter:       0.5,
+		retryable:    IsRetryable,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *RetryLoader) do(ctx context.Context, timeout bool, fn func(ctx context.Context) error) error {
+	delay := r.initialDelay
+	for attempt := 1; ; attempt++ {
+		err := r.attempt(ctx, timeout, fn)
+		if err == nil || attempt >= r.maxAttempts || ctx.Err() != nil || !r.retryable(err) {
+			return err
+		}
+		wait := delay
+		var loadErr *LoadError
+		if errors.As(err, &loadErr) && loadErr.RetryAfter > 0 {
+			if loadErr.RetryAfter > r.maxDelay {
+				return err
+			}
+			wait = max(wait, loadErr.RetryAfter)
+		} else if r.jitter > 0 {
+			wait -= time.Duration(rand.Float64() * r.jitter * float64(wait))
+		}
+		if !sleep(ctx, wait) {
+			return err
+		}
+		delay = min(delay*2, r.maxDelay)
+	}
+}
+
+func (r *RetryLoader) attempt(ctx context.Context, timeout bool, fn func(ctx context.Context) error) error {
+	if timeout && r.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.attemptTimeout)
+		defer cancel()
+	}
+	return fn(ctx)
+}
+
+func (r *RetryLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (file *File, err error) {
+	err = r.do(ctx, true, func(ctx context.Context) error {
+		file, err = r.loader.Load(ctx, path, opt...)
+		return err
+	})
+	return file, err
+}
+
+func (r *RetryLoader) unwrap() Loader {
+	return r.loader
+}
+
+// LoadBatch retries the paths of a *BatchError whose errors are retryable, and a failed batch as a whole.
+func (r *RetryLoader) LoadBatch(ctx context.Context, paths []string, opt ...LoaderOption) (map[string]*File, error) {
+	result := make(map[string]*File, len(paths))
+	errs := make(map[string]error)
+	pending := paths
+	err := r.do(ctx, true, func(ctx context.Context) error {
+		files, err := loadBatch(ctx, r.loader, pending, opt...)
+		for k, v := range files {
+			result[k] = v
+		}
+		var batchErr *BatchError
+		if err == nil || !errors.As(err, &batchErr) {
+			return err
+		}
+		retry := &BatchError{Errors: make(map[string]error)}
+		pending = nil
+		for k, v := range batchErr.Errors {
+			if r.retryable(v) {
+				retry.Errors[k] = v
+				pending = append(pending, k)
+			} else {
+				errs[k] = v
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		return retry
+	})
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		for k, v := range batchErr.Errors {
+			errs[k] = v
+		}
+	} else if err != nil {
+		return result, err
+	}
+	if len(errs) > 0 {
+		return result, &BatchError{Errors: errs}
+	}
+	return result, nil
+}
+
+func (r *RetryLoader) List(ctx context.Context, path string, opt ...LoaderOption) (list []string, err error) {
+	err = r.do(ctx, true, func(ctx context.Context) error {
+		list, err = r.loader.List(ctx, path, opt...)
+		return err
+	})
+	return list, err
+}
+
+func (r *RetryLoader) Open(ctx context.Context, path string, opt ...LoaderOption) (rc io.ReadCloser, file *File, err error) {
+	err = r.do(ctx, false, func(ctx context.Context) error {
+		rc, file, err = open(ctx, r.loader, path, opt...)
+		return err
+	})
+	return rc, file, err
+}
+
+func (r *RetryLoader) Save(ctx context.Context, path string, body io.Reader, opt ...LoaderOption) (file *File, err error) {
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		return save(ctx, r.loader, path, body, opt...)
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	err = r.do(ctx, true, func(ctx context.Context) error {
+		if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		file, err = save(ctx, r.loader, path, body, opt...)
+		return err
+	})
+	return file, err
+}
+
+func (r *RetryLoader) Stat(ctx context.Context, path string, opt ...LoaderOption) (info *FileInfo, err error) {
+	err = r.do(ctx, true, func(ctx context.Context) error {
+		info, err = stat(ctx, r.loader, path, opt...)
+		return err
+	})
+	return info, err
+}
+
+func (r *RetryLoader) Delete(ctx context.Context, path string, opt ...LoaderOption) error {
+	return r.do(ctx, true, func(ctx context.Context) error {
+		return remove(ctx, r.loader, path, opt...)
+	})
+}
+
+func (r *RetryLoader) ListEntries(ctx context.Context, path string, opt ...LoaderOption) (entries []Entry, err error) {
+	err = r.do(ctx, true, func(ctx context.Context) error {
+		entries, err = listEntries(ctx, r.loader, path, opt...)
+		return err
+	})
+	return entries, err
+}
+
+func (r *RetryLoader) Walk(ctx context.Context, path string, fn WalkFunc, opt ...LoaderOption) error {
+	return walk(ctx, r.loader, path, fn, opt...)
+}
+
+// Watch polls through the RetryLoader unless the wrapped loader watches by itself.
+func (r *RetryLoader) Watch(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error) {
+	if v, ok := r.loader.(Watcher); ok {
+		return v.Watch(ctx, path, opt...)
+	}
+	return Poll(ctx, r, path, opt...)
+}
@@ -1,88 +1,332 @@
 package s3loader
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
-	"net/url"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/goccha/fileloaders"
 )
 
 type Client interface {
 	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
 	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
 }
 
-func Load(ctx context.Context, api Client, path string) (*fileloaders.File, error) {
+// objectKey returns the object key and the "?version=" query parameter of file.
+func objectKey(file *fileloaders.File) (key string, version *string) {
+	if u := file.URI(); u.Has("version") {
+		version = aws.String(u.Get("version"))
+	}
+	return file.Path, version
+}
+
+// clientOptions applies the "?region=" query parameter of file to a single call.
+func clientOptions(file *fileloaders.File) []func(*s3.Options) {
+	if region := file.URI().Get("region"); region != "" {
+		return []func(*s3.Options){func(o *s3.Options) {
+			o.Region = region
+		}}
+	}
+	return nil
+}
+
+func entryURI(bucket, key string) string {
+	return (&fileloaders.URI{Scheme: "s3", Bucket: bucket, Key: key}).String()
+}
+
+func wrapError(file *fileloaders.File, err error) error {
+	var kind error
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	var noSuchBucket *types.NoSuchBucket
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) || errors.As(err, &noSuchBucket) {
+		kind = fileloaders.ErrNotExist
+	} else if retryable(err) {
+		kind = fileloaders.ErrTransient
+	}
+	return fileloaders.NewLoadError(file.Type, file.Bucket+"/"+file.Path, kind, err)
+}
+
+// retryable reports the errors the AWS SDK itself treats as retryable or as throttling.
+func retryable(err error) bool {
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary ||
+		retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
+
+func Open(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) (io.ReadCloser, *fileloaders.File, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "s3" || file.Bucket == "" {
+		return nil, nil, fileloaders.ErrNotSupported
+	}
+	key, version := objectKey(file)
+	in := &s3.GetObjectInput{
+		Bucket:    aws.String(file.Bucket),
+		Key:       aws.String(key),
+		VersionId: version,
+	}
+	o := fileloaders.NewOptions(opt...)
+	if o.Previous != nil {
+		if etag, ok := o.Previous.ETag(); ok {
+			in.IfNoneMatch = aws.String(etag)
+		}
+	}
+	if o.Range != nil {
+		in.Range = aws.String(o.Range.Header())
+	}
+	result, err := api.GetObject(ctx, in, clientOptions(file)...)
+	if err != nil {
+		return nil, nil, wrapError(file, err)
+	}
+	if start, _, _, ok := fileloaders.ParseContentRange(aws.ToString(result.ContentRange)); ok {
+		file.Add(fileloaders.WithOffset(start))
+	}
+	return result.Body, file.Add(
+		fileloaders.WithHash(result.ETag),
+		fileloaders.WithVersion(result.VersionId),
+		fileloaders.WithContentType(result.ContentType)), nil
+}
+
+func Load(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	r, file, err := Open(ctx, api, path, opt...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return file.WriteBody(body), nil
+}
+
+func Save(ctx context.Context, api Client, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
 	file := fileloaders.Parse(path)
 	if file == nil || file.Type != "s3" || file.Bucket == "" {
 		return nil, fileloaders.ErrNotSupported
 	}
-	var version *string
-	path = file.Path
-	if index := strings.LastIndex(path, "?"); index > 0 {
-		if query, err := url.ParseQuery(path[index+1:]); err != nil {
+	loader := New(api)
+	for _, v := range opt {
+		v(loader)
+	}
+	if _, ok := body.(io.Seeker); !ok {
+		bin, err := io.ReadAll(body)
+		if err != nil {
 			return nil, err
-		} else if query.Has("version") {
-			version = aws.String(query.Get("version"))
 		}
-		path = path[:index]
+		body = bytes.NewReader(bin)
+	}
+	in := &s3.PutObjectInput{
+		Bucket: aws.String(file.Bucket),
+		Key:    aws.String(file.Path),
+		Body:   body,
+	}
+	if loader.contentType != "" {
+		in.ContentType = aws.String(loader.contentType)
+	}
+	out, err := api.PutObject(ctx, in, clientOptions(file)...)
+	if err != nil {
+		return nil, wrapError(file, err)
+	}
+	return file.Add(
+		fileloaders.WithHash(out.ETag),
+		fileloaders.WithVersion(out.VersionId)), nil
+}
+
+func Stat(ctx context.Context, api Client, path string) (*fileloaders.FileInfo, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "s3" || file.Bucket == "" {
+		return nil, fileloaders.ErrNotSupported
 	}
-	result, err := api.GetObject(ctx, &s3.GetObjectInput{
+	key, version := objectKey(file)
+	out, err := api.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket:    aws.String(file.Bucket),
-		Key:       aws.String(path),
+		Key:       aws.String(key),
 		VersionId: version,
-	})
+	}, clientOptions(file)...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(file, err)
 	}
-	defer func() {
-		_ = result.Body.Close()
-	}()
-	body, err := io.ReadAll(result.Body)
+	return &fileloaders.FileInfo{
+		Type:    file.Type,
+		Bucket:  file.Bucket,
+		Path:    key,
+		Size:    aws.ToInt64(out.ContentLength),
+		ModTime: aws.ToTime(out.LastModified),
+		Hash:    aws.ToString(out.ETag),
+		Version: aws.ToString(out.VersionId),
+	}, nil
+}
+
+func Delete(ctx context.Context, api Client, path string) error {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "s3" || file.Bucket == "" {
+		return fileloaders.ErrNotSupported
+	}
+	key, version := objectKey(file)
+	_, err := api.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(file.Bucket),
+		Key:       aws.String(key),
+		VersionId: version,
+	}, clientOptions(file)...)
+	return wrapError(file, err)
+}
+
+func List(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	entries, err := ListEntries(ctx, api, path, opt...)
 	if err != nil {
 		return nil, err
 	}
-	return file.WriteBody(body).Add(
-		fileloaders.WithHash(result.ETag),
-		fileloaders.WithVersion(result.VersionId)), nil
+	return fileloaders.EntryNames(entries), nil
 }
 
-func List(ctx context.Context, api Client, path string) ([]string, error) {
+func ListEntries(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return fileloaders.CollectEntries(func(fn fileloaders.WalkFunc) error {
+		return Walk(ctx, api, path, fn, opt...)
+	})
+}
+
+// Walk lists the directory path. Entry names are relative to path and subdirectories
+// are listed too when the Recursive option is set.
+func Walk(ctx context.Context, api Client, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
 	filePath := fileloaders.Parse(path)
 	if filePath == nil || filePath.Type != "s3" || filePath.Bucket == "" {
-		return nil, fileloaders.ErrNotSupported
+		return fileloaders.ErrNotSupported
+	}
+	dir := filePath.Path
+	if dir != "" && !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	return walk(ctx, api, filePath, dir, dir, fn, opt...)
+}
+
+// WalkPrefix lists the objects whose keys begin with the key of prefix, which need not be a directory.
+// Entry names are relative to the directory of the prefix.
+func WalkPrefix(ctx context.Context, api Client, prefix string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	filePath := fileloaders.Parse(prefix)
+	if filePath == nil || filePath.Type != "s3" || filePath.Bucket == "" {
+		return fileloaders.ErrNotSupported
 	}
+	dir := filePath.Path[:strings.LastIndex(filePath.Path, "/")+1]
+	return walk(ctx, api, filePath, filePath.Path, dir, fn, opt...)
+}
+
+func walk(ctx context.Context, api Client, filePath *fileloaders.File, prefix, dir string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
 	in := &s3.ListObjectsV2Input{
 		Bucket: aws.String(filePath.Bucket),
 	}
-	if filePath.Path != "" {
-		in.Prefix = aws.String(filePath.Path)
+	if prefix != "" {
+		in.Prefix = aws.String(prefix)
 	}
-	out, err := api.ListObjectsV2(ctx, in)
-	if err != nil {
-		return nil, err
+	if !fileloaders.NewOptions(opt...).Recursive {
+		in.Delimiter = aws.String("/")
 	}
-	result := make([]string, len(out.Contents))
-	for i, v := range out.Contents {
-		result[i] = *v.Key
+	for {
+		out, err := api.ListObjectsV2(ctx, in, clientOptions(filePath)...)
+		if err != nil {
+			return wrapError(filePath, err)
+		}
+		for _, v := range out.CommonPrefixes {
+			if err = fn(fileloaders.Entry{
+				Name: strings.TrimSuffix(strings.TrimPrefix(*v.Prefix, dir), "/"),
+				URI:  entryURI(filePath.Bucket, *v.Prefix),
+				Kind: fileloaders.KindDir,
+			}); err != nil {
+				if errors.Is(err, fileloaders.SkipAll) {
+					return nil
+				}
+				return err
+			}
+		}
+		for _, v := range out.Contents {
+			name := strings.TrimPrefix(*v.Key, dir)
+			if name == "" {
+				// the placeholder object of the directory itself
+				continue
+			}
+			if err = fn(fileloaders.Entry{
+				Name:    name,
+				URI:     entryURI(filePath.Bucket, *v.Key),
+				Size:    aws.ToInt64(v.Size),
+				ModTime: aws.ToTime(v.LastModified),
+				Hash:    aws.ToString(v.ETag),
+				Kind:    fileloaders.KindFile,
+			}); err != nil {
+				if errors.Is(err, fileloaders.SkipAll) {
+					return nil
+				}
+				return err
+			}
+		}
+		if !aws.ToBool(out.IsTruncated) || out.NextContinuationToken == nil {
+			return nil
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		in.ContinuationToken = out.NextContinuationToken
 	}
-	return result, nil
 }
 
 type Loader struct {
-	client Client
+	client      Client
+	contentType string
+}
+
+func WithContentType(contentType string) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.contentType = contentType
+		}
+	}
 }
 
 func (l *Loader) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
-	return Load(ctx, l.client, path)
+	return Load(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) Open(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (io.ReadCloser, *fileloaders.File, error) {
+	return Open(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) Save(ctx context.Context, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return Save(ctx, l.client, path, body, opt...)
+}
+
+func (l *Loader) Stat(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.FileInfo, error) {
+	return Stat(ctx, l.client, path)
+}
+
+func (l *Loader) Delete(ctx context.Context, path string, opt ...fileloaders.LoaderOption) error {
+	return Delete(ctx, l.client, path)
 }
 
 func (l *Loader) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
-	return List(ctx, l.client, path)
+	return List(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) ListEntries(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return ListEntries(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) Walk(ctx context.Context, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	return Walk(ctx, l.client, path, fn, opt...)
+}
+
+// WalkPrefix makes Glob list objects from the whole literal prefix of a pattern.
+func (l *Loader) WalkPrefix(ctx context.Context, prefix string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	return WalkPrefix(ctx, l.client, prefix, fn, opt...)
 }
 
 func New(api Client) *Loader {
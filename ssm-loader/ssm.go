@@ -2,17 +2,83 @@ package ssmloader
 
 import (
 	"context"
+	"errors"
+	"io"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
 	"github.com/goccha/fileloaders"
 )
 
 type Client interface {
 	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
 	DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error)
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+	DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error)
+}
+
+func wrapError(file *fileloaders.File, err error) error {
+	var kind error
+	var notFound *types.ParameterNotFound
+	var versionNotFound *types.ParameterVersionNotFound
+	var apiErr smithy.APIError
+	if errors.As(err, &notFound) || errors.As(err, &versionNotFound) {
+		kind = fileloaders.ErrNotExist
+	} else if errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDeniedException" {
+		kind = fileloaders.ErrPermission
+	} else if retryable(err) {
+		kind = fileloaders.ErrTransient
+	}
+	return fileloaders.NewLoadError(file.Type, parameterName(file), kind, err)
+}
+
+// retryable reports the errors the AWS SDK itself treats as retryable or as throttling, such as ThrottlingException.
+func retryable(err error) bool {
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary ||
+		retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
+
+func parameterName(file *fileloaders.File) string {
+	if file.Bucket != "" {
+		return "/" + file.Bucket + "/" + file.Path
+	}
+	return "/" + file.Path
+}
+
+// parameterSelector returns the parameter name followed by the "?version=" or "?label=" query parameter as a selector.
+func parameterSelector(file *fileloaders.File) string {
+	u := file.URI()
+	if v := u.Get("version"); v != "" {
+		return parameterName(file) + ":" + v
+	}
+	if v := u.Get("label"); v != "" {
+		return parameterName(file) + ":" + v
+	}
+	return parameterName(file)
+}
+
+// decrypt reads the "?decrypt=" query parameter. SecureString parameters are decrypted by default.
+func decrypt(file *fileloaders.File) bool {
+	if v, err := strconv.ParseBool(file.URI().Get("decrypt")); err == nil {
+		return v
+	}
+	return true
+}
+
+// clientOptions applies the "?region=" query parameter of file to a single call.
+func clientOptions(file *fileloaders.File) []func(*ssm.Options) {
+	if region := file.URI().Get("region"); region != "" {
+		return []func(*ssm.Options){func(o *ssm.Options) {
+			o.Region = region
+		}}
+	}
+	return nil
 }
 
 func Load(ctx context.Context, api Client, path string) (*fileloaders.File, error) {
@@ -20,18 +86,12 @@ func Load(ctx context.Context, api Client, path string) (*fileloaders.File, erro
 	if file == nil || file.Type != "ssm" || file.Bucket == "" {
 		return nil, fileloaders.ErrNotSupported
 	}
-	var key string
-	if file.Bucket != "" {
-		key = "/" + file.Bucket + "/" + file.Path
-	} else {
-		key = "/" + file.Path
-	}
 	out, err := api.GetParameter(ctx, &ssm.GetParameterInput{
-		Name:           aws.String(key),
-		WithDecryption: aws.Bool(true),
-	})
+		Name:           aws.String(parameterSelector(file)),
+		WithDecryption: aws.Bool(decrypt(file)),
+	}, clientOptions(file)...)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(file, err)
 	}
 	if out.Parameter != nil {
 		if out.Parameter.Version > 0 {
@@ -45,10 +105,168 @@ func Load(ctx context.Context, api Client, path string) (*fileloaders.File, erro
 	return &fileloaders.File{}, nil
 }
 
-func List(ctx context.Context, api Client, path string) ([]string, error) {
+// maxBatchSize is the number of names GetParameters accepts in a single call.
+const maxBatchSize = 10
+
+// LoadBatch loads the parameters with GetParameters, ten names per call. Paths are grouped by
+// their "?region=" and "?decrypt=" query parameters, since those apply to a whole call.
+// Paths that are not ssm URIs fail with ErrNotSupported and unknown parameters with ErrNotExist.
+func LoadBatch(ctx context.Context, api Client, paths []string) (map[string]*fileloaders.File, error) {
+	type group struct {
+		region  string
+		decrypt bool
+	}
+	result := make(map[string]*fileloaders.File, len(paths))
+	errs := make(map[string]error)
+	groups := make(map[group][]string)
+	bySelector := make(map[group]map[string][]string)
+	var order []group
+	for _, path := range paths {
+		file := fileloaders.Parse(path)
+		if file == nil || file.Type != "ssm" || file.Bucket == "" {
+			errs[path] = fileloaders.ErrNotSupported
+			continue
+		}
+		g := group{region: file.URI().Get("region"), decrypt: decrypt(file)}
+		if _, ok := bySelector[g]; !ok {
+			bySelector[g] = make(map[string][]string)
+			order = append(order, g)
+		}
+		selector := parameterSelector(file)
+		if _, ok := bySelector[g][selector]; !ok {
+			groups[g] = append(groups[g], selector)
+		}
+		bySelector[g][selector] = append(bySelector[g][selector], path)
+	}
+	for _, g := range order {
+		names, byName := groups[g], bySelector[g]
+		file := fileloaders.Parse(byName[names[0]][0])
+		for start := 0; start < len(names); start += maxBatchSize {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			chunk := names[start:min(start+maxBatchSize, len(names))]
+			out, err := api.GetParameters(ctx, &ssm.GetParametersInput{
+				Names:          chunk,
+				WithDecryption: aws.Bool(g.decrypt),
+			}, clientOptions(file)...)
+			if err != nil {
+				for _, name := range chunk {
+					for _, path := range byName[name] {
+						errs[path] = wrapError(fileloaders.Parse(path), err)
+					}
+				}
+				continue
+			}
+			for _, name := range out.InvalidParameters {
+				for _, path := range byName[name] {
+					errs[path] = fileloaders.NewLoadError("ssm", name, fileloaders.ErrNotExist, errors.New("parameter not found"))
+				}
+			}
+			for _, parameter := range out.Parameters {
+				for _, path := range byName[aws.ToString(parameter.Name)+aws.ToString(parameter.Selector)] {
+					file := fileloaders.Parse(path)
+					if parameter.Version > 0 {
+						version := strconv.FormatInt(parameter.Version, 10)
+						file.Add(fileloaders.WithVersion(&version))
+					}
+					result[path] = file.WriteBody([]byte(aws.ToString(parameter.Value)))
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return result, &fileloaders.BatchError{Errors: errs}
+	}
+	return result, nil
+}
+
+func Save(ctx context.Context, api Client, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "ssm" || file.Bucket == "" {
+		return nil, fileloaders.ErrNotSupported
+	}
+	loader := New(api)
+	for _, v := range opt {
+		v(loader)
+	}
+	value, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	out, err := api.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(parameterName(file)),
+		Value:     aws.String(string(value)),
+		Type:      loader.parameterType,
+		Overwrite: aws.Bool(loader.overwrite),
+	}, clientOptions(file)...)
+	if err != nil {
+		return nil, wrapError(file, err)
+	}
+	version := strconv.FormatInt(out.Version, 10)
+	return file.Add(fileloaders.WithVersion(&version)), nil
+}
+
+func Stat(ctx context.Context, api Client, path string) (*fileloaders.FileInfo, error) {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "ssm" || file.Bucket == "" {
+		return nil, fileloaders.ErrNotSupported
+	}
+	out, err := api.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(parameterSelector(file)),
+		WithDecryption: aws.Bool(false),
+	}, clientOptions(file)...)
+	if err != nil {
+		return nil, wrapError(file, err)
+	}
+	info := &fileloaders.FileInfo{
+		Type:   file.Type,
+		Bucket: file.Bucket,
+		Path:   file.Path,
+	}
+	if out.Parameter != nil {
+		info.ModTime = aws.ToTime(out.Parameter.LastModifiedDate)
+		if out.Parameter.Version > 0 {
+			info.Version = strconv.FormatInt(out.Parameter.Version, 10)
+		}
+		if out.Parameter.Type != types.ParameterTypeSecureString {
+			info.Size = int64(len(aws.ToString(out.Parameter.Value)))
+		}
+	}
+	return info, nil
+}
+
+func Delete(ctx context.Context, api Client, path string) error {
+	file := fileloaders.Parse(path)
+	if file == nil || file.Type != "ssm" || file.Bucket == "" {
+		return fileloaders.ErrNotSupported
+	}
+	_, err := api.DeleteParameter(ctx, &ssm.DeleteParameterInput{
+		Name: aws.String(parameterName(file)),
+	}, clientOptions(file)...)
+	return wrapError(file, err)
+}
+
+func List(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	entries, err := ListEntries(ctx, api, path, opt...)
+	if err != nil {
+		return nil, err
+	}
+	return fileloaders.EntryNames(entries), nil
+}
+
+func ListEntries(ctx context.Context, api Client, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return fileloaders.CollectEntries(func(fn fileloaders.WalkFunc) error {
+		return Walk(ctx, api, path, fn, opt...)
+	})
+}
+
+// Walk lists the parameters below the path, which is treated as a directory. Entry names are relative to it.
+// Unless WithRecursive is set, parameters below the next "/" are reported once as a directory entry, like an S3 delimiter listing.
+func Walk(ctx context.Context, api Client, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
 	filePath := fileloaders.Parse(path)
 	if filePath == nil || filePath.Type != "ssm" {
-		return nil, fileloaders.ErrNotSupported
+		return fileloaders.ErrNotSupported
 	}
 	var key string
 	if filePath.Bucket != "" {
@@ -59,8 +277,11 @@ func List(ctx context.Context, api Client, path string) ([]string, error) {
 	} else {
 		key = "/" + filePath.Path
 	}
+	if !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
 	input := &ssm.DescribeParametersInput{}
-	if key != "" {
+	if key != "/" {
 		input = &ssm.DescribeParametersInput{
 			ParameterFilters: []types.ParameterStringFilter{
 				{
@@ -71,31 +292,128 @@ func List(ctx context.Context, api Client, path string) ([]string, error) {
 			},
 		}
 	}
-	out, err := api.DescribeParameters(ctx, input)
-	if err != nil {
-		return nil, err
-	}
-	result := make([]string, 0, len(out.Parameters))
-	for _, v := range out.Parameters {
-		result = append(result, *v.Name)
+	recursive := fileloaders.NewOptions(opt...).Recursive
+	dirs := make(map[string]struct{})
+	for {
+		out, err := api.DescribeParameters(ctx, input, clientOptions(filePath)...)
+		if err != nil {
+			return wrapError(filePath, err)
+		}
+		for _, v := range out.Parameters {
+			name := strings.TrimPrefix(*v.Name, key)
+			if !recursive {
+				if index := strings.Index(name, "/"); index >= 0 {
+					name = name[:index]
+					if _, ok := dirs[name]; ok {
+						continue
+					}
+					dirs[name] = struct{}{}
+					if err = fn(fileloaders.Entry{
+						Name: name,
+						URI:  "ssm:/" + key + name + "/",
+						Kind: fileloaders.KindDir,
+					}); err != nil {
+						if errors.Is(err, fileloaders.SkipAll) {
+							return nil
+						}
+						return err
+					}
+					continue
+				}
+			}
+			entry := fileloaders.Entry{
+				Name:    name,
+				URI:     "ssm:/" + *v.Name,
+				ModTime: aws.ToTime(v.LastModifiedDate),
+				Kind:    fileloaders.KindFile,
+			}
+			if v.Version > 0 {
+				entry.Version = strconv.FormatInt(v.Version, 10)
+			}
+			if err = fn(entry); err != nil {
+				if errors.Is(err, fileloaders.SkipAll) {
+					return nil
+				}
+				return err
+			}
+		}
+		if aws.ToString(out.NextToken) == "" {
+			return nil
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		input.NextToken = out.NextToken
 	}
-	return result, nil
 }
 
 type Loader struct {
-	client Client
+	client        Client
+	overwrite     bool
+	parameterType types.ParameterType
+}
+
+// WithOverwrite(false) makes Save fail when the parameter already exists. Existing parameters are replaced by default.
+func WithOverwrite(overwrite bool) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.overwrite = overwrite
+		}
+	}
+}
+
+// WithType sets the type of saved parameters, such as SecureString. The default is String.
+func WithType(parameterType types.ParameterType) fileloaders.LoaderOption {
+	return func(l fileloaders.Loader) {
+		if v, ok := l.(*Loader); ok {
+			v.parameterType = parameterType
+		}
+	}
 }
 
 func (l *Loader) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
 	return Load(ctx, l.client, path)
 }
 
+// BatchSize makes LoadAll split batches into GetParameters calls that run concurrently.
+func (l *Loader) BatchSize() int {
+	return maxBatchSize
+}
+
+func (l *Loader) LoadBatch(ctx context.Context, paths []string, opt ...fileloaders.LoaderOption) (map[string]*fileloaders.File, error) {
+	return LoadBatch(ctx, l.client, paths)
+}
+
+func (l *Loader) Save(ctx context.Context, path string, body io.Reader, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return Save(ctx, l.client, path, body, opt...)
+}
+
+func (l *Loader) Stat(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.FileInfo, error) {
+	return Stat(ctx, l.client, path)
+}
+
+func (l *Loader) Delete(ctx context.Context, path string, opt ...fileloaders.LoaderOption) error {
+	return Delete(ctx, l.client, path)
+}
+
 func (l *Loader) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
-	return List(ctx, l.client, path)
+	return List(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) ListEntries(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]fileloaders.Entry, error) {
+	return ListEntries(ctx, l.client, path, opt...)
+}
+
+func (l *Loader) Walk(ctx context.Context, path string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	return Walk(ctx, l.client, path, fn, opt...)
 }
 
 func New(api Client) *Loader {
-	return &Loader{client: api}
+	return &Loader{
+		client:        api,
+		overwrite:     true,
+		parameterType: types.ParameterTypeString,
+	}
 }
 
 func With(api Client) fileloaders.Option {
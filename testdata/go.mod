@@ -12,11 +12,16 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/ssm v1.56.0
 	github.com/aws/smithy-go v1.22.1
 	github.com/goccha/fileloaders v0.0.1-alpha.7
+	github.com/goccha/fileloaders/dotenv-decoder v0.0.0-00010101000000-000000000000
+	github.com/goccha/fileloaders/fsnotify-watcher v0.0.0-00010101000000-000000000000
 	github.com/goccha/fileloaders/github-loader v0.0.0-00010101000000-000000000000
 	github.com/goccha/fileloaders/gs-loader v0.0.0-20200522141810-8b9b9c9b1b0e
 	github.com/goccha/fileloaders/http-loader v0.0.0-20200522141810-8b9b9c9b1b0e
+	github.com/goccha/fileloaders/ini-decoder v0.0.0-00010101000000-000000000000
 	github.com/goccha/fileloaders/s3-loader v0.0.0-20200522141810-8b9b9c9b1b0e
 	github.com/goccha/fileloaders/ssm-loader v0.0.0-20200522141810-8b9b9c9b1b0e
+	github.com/goccha/fileloaders/toml-decoder v0.0.0-00010101000000-000000000000
+	github.com/goccha/fileloaders/yaml-decoder v0.0.0-00010101000000-000000000000
 	github.com/google/go-github/v66 v66.0.0
 	google.golang.org/api v0.209.0
 )
@@ -29,6 +34,7 @@ require (
 	cloud.google.com/go/compute/metadata v0.5.2 // indirect
 	cloud.google.com/go/iam v1.2.2 // indirect
 	cloud.google.com/go/monitoring v1.21.2 // indirect
+	github.com/BurntSushi/toml v1.6.0 // indirect
 	github.com/GoogleCloudPlatform/opentelemetry-operations-go/detectors/gcp v1.25.0 // indirect
 	github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/metric v0.49.0 // indirect
 	github.com/GoogleCloudPlatform/opentelemetry-operations-go/internal/resourcemapping v0.49.0 // indirect
@@ -52,6 +58,7 @@ require (
 	github.com/envoyproxy/go-control-plane v0.13.1 // indirect
 	github.com/envoyproxy/protoc-gen-validate v1.1.0 // indirect
 	github.com/felixge/httpsnoop v1.0.4 // indirect
+	github.com/fsnotify/fsnotify v1.7.0 // indirect
 	github.com/go-logr/logr v1.4.2 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
@@ -61,6 +68,7 @@ require (
 	github.com/googleapis/enterprise-certificate-proxy v0.3.4 // indirect
 	github.com/googleapis/gax-go/v2 v2.14.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/joho/godotenv v1.5.1 // indirect
 	github.com/planetscale/vtprotobuf v0.6.1-0.20240319094008-0393e58bdf10 // indirect
 	go.opencensus.io v0.24.0 // indirect
 	go.opentelemetry.io/contrib/detectors/gcp v1.32.0 // indirect
@@ -84,13 +92,20 @@ require (
 	google.golang.org/grpc v1.68.0 // indirect
 	google.golang.org/grpc/stats/opentelemetry v0.0.0-20241028142157-ada6787961b3 // indirect
 	google.golang.org/protobuf v1.35.2 // indirect
+	gopkg.in/ini.v1 v1.67.3 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace (
 	github.com/goccha/fileloaders => ../
+	github.com/goccha/fileloaders/dotenv-decoder => ../dotenv-decoder
+	github.com/goccha/fileloaders/fsnotify-watcher => ../fsnotify-watcher
 	github.com/goccha/fileloaders/github-loader => ../github-loader
 	github.com/goccha/fileloaders/gs-loader => ../gs-loader
 	github.com/goccha/fileloaders/http-loader => ../http-loader
+	github.com/goccha/fileloaders/ini-decoder => ../ini-decoder
 	github.com/goccha/fileloaders/s3-loader => ../s3-loader
 	github.com/goccha/fileloaders/ssm-loader => ../ssm-loader
+	github.com/goccha/fileloaders/toml-decoder => ../toml-decoder
+	github.com/goccha/fileloaders/yaml-decoder => ../yaml-decoder
 )
@@ -4,28 +4,50 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"cloud.google.com/go/storage"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/aws/smithy-go/logging"
 	"github.com/goccha/fileloaders"
+	"github.com/goccha/fileloaders/dotenv-decoder"
+	"github.com/goccha/fileloaders/fsnotify-watcher"
 	"github.com/goccha/fileloaders/github-loader"
 	"github.com/goccha/fileloaders/gs-loader"
 	"github.com/goccha/fileloaders/http-loader"
+	"github.com/goccha/fileloaders/ini-decoder"
 	"github.com/goccha/fileloaders/s3-loader"
 	"github.com/goccha/fileloaders/ssm-loader"
+	"github.com/goccha/fileloaders/toml-decoder"
+	"github.com/goccha/fileloaders/yaml-decoder"
 	"github.com/google/go-github/v66/github"
 	"google.golang.org/api/option"
 )
@@ -177,25 +199,1576 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestOpen(t *testing.T) {
+	r, file, err := fileloaders.Open(context.Background(), "file://../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(body), "# fileloaders") || file.Path != "../README.md" {
+		t.Fatal("invalid open")
+	}
+}
+
+func TestSave(t *testing.T) {
+	ctx := context.Background()
+	path := "file://" + t.TempDir() + "/config.json"
+	for _, v := range []string{`{"v":1}`, `{"v":2}`} {
+		if _, err := fileloaders.Save(ctx, path, strings.NewReader(v)); err != nil {
+			t.Fatal(err)
+		}
+		file, err := fileloaders.Load(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(file.GetBody()) != v {
+			t.Fatal("invalid save")
+		}
+	}
+	// a scheme without a loader must not be written to the local disk
+	if _, err := fileloaders.Save(ctx, "unknown://bucket/key", strings.NewReader("{}")); !errors.Is(err, fileloaders.ErrNotSupported) {
+		t.Fatal("expected not supported", err)
+	}
+	if _, err := os.Stat("unknown:"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("unexpected local directory", err)
+	}
+}
+
+func TestStat(t *testing.T) {
+	ctx := context.Background()
+	path := "file://" + t.TempDir() + "/config.json"
+	if _, err := fileloaders.Save(ctx, path, strings.NewReader("{}")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := fileloaders.Stat(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 2 || info.IsDir {
+		t.Fatal("invalid stat")
+	}
+	if err = fileloaders.Delete(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fileloaders.Stat(ctx, path); !errors.Is(err, fileloaders.ErrNotExist) {
+		t.Fatal(err)
+	}
+
+	// a scheme without a loader must not reach a local file with the same relative path
+	if err = os.MkdirAll("unknown:/bucket", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll("unknown:")
+	}()
+	if err = os.WriteFile("unknown:/bucket/key", []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fileloaders.Stat(ctx, "unknown://bucket/key"); !errors.Is(err, fileloaders.ErrNotSupported) {
+		t.Fatal("expected not supported", err)
+	}
+	if err = fileloaders.Delete(ctx, "unknown://bucket/key"); !errors.Is(err, fileloaders.ErrNotSupported) {
+		t.Fatal("expected not supported", err)
+	}
+	if _, err = os.Stat("unknown:/bucket/key"); err != nil {
+		t.Fatal("local file removed", err)
+	}
+}
+
+func TestListEntries(t *testing.T) {
+	entries, err := fileloaders.ListEntries(context.Background(), "file://..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, v := range entries {
+		if v.Name == "testdata" {
+			found = v.IsDir() && v.URI == "file://../testdata"
+		}
+	}
+	if !found {
+		t.Fatal("invalid entries")
+	}
+
+	list, err := fileloaders.List(context.Background(), "file://..", fileloaders.WithRecursive(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = false
+	for _, v := range list {
+		found = found || v == "testdata/load_test.go"
+	}
+	if !found {
+		t.Fatal("invalid recursive list")
+	}
+}
+
+func TestGlob(t *testing.T) {
+	ctx := context.Background()
+	list, err := fileloaders.Glob(ctx, "../**/load_*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0] != "../testdata/load_test.go" {
+		t.Fatal("invalid glob", list)
+	}
+	// a recursive pattern must not write into the spare capacity of the caller's options
+	opts := make([]fileloaders.LoaderOption, 1, 2)
+	opts[0] = fileloaders.WithRecursive(false)
+	if list, err = fileloaders.Glob(ctx, "../**/load_*.go", opts...); err != nil || len(list) != 1 {
+		t.Fatal("invalid glob with options", list, err)
+	}
+	if opts[:2][1] != nil {
+		t.Fatal("caller options modified")
+	}
+	files, err := fileloaders.LoadGlob(ctx, "file://../README.[m]d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || !strings.HasPrefix(string(files[0].GetBody()), "# fileloaders") {
+		t.Fatal("invalid load glob")
+	}
+
+	store := &prefixStore{keys: []string{"logs/app 1.json", "logs/app 2.txt", "logs/web.json"}}
+	m := fileloaders.New()
+	m.Register("mem", store)
+	list, err = fileloaders.Glob(fileloaders.NewContext(ctx, m), "mem://b/logs/app *.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.listed != "mem://b/logs/app " || len(list) != 1 || list[0] != "mem://b/logs/app%201.json" {
+		t.Fatal("invalid prefix glob", store.listed, list)
+	}
+}
+
+// prefixStore lists its keys by prefix and reports percent-encoded URIs, as the object stores do.
+type prefixStore struct {
+	keys   []string
+	listed string
+	loads  int
+}
+
+func (s *prefixStore) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	file := fileloaders.Parse(path)
+	for _, v := range s.keys {
+		if v == file.Path {
+			s.loads++
+			return file.WriteBody([]byte(v)), nil
+		}
+	}
+	return nil, fileloaders.NewLoadError("mem", path, fileloaders.ErrNotExist, errors.New("not found"))
+}
+
+func (s *prefixStore) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	return nil, fileloaders.ErrNotSupported
+}
+
+func (s *prefixStore) WalkPrefix(ctx context.Context, prefix string, fn fileloaders.WalkFunc, opt ...fileloaders.LoaderOption) error {
+	s.listed = prefix
+	file := fileloaders.Parse(prefix)
+	dir := file.Path[:strings.LastIndex(file.Path, "/")+1]
+	for _, v := range s.keys {
+		if strings.HasPrefix(v, file.Path) {
+			if err := fn(fileloaders.Entry{Name: strings.TrimPrefix(v, dir), URI: (&fileloaders.URI{Scheme: "mem", Bucket: file.Bucket, Key: v}).String()}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// s3Store is an s3loader.Client listing its keys pageSize results at a time.
+type s3Store struct {
+	keys     []string
+	pageSize int
+	calls    int
+}
+
+func (s *s3Store) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *s3Store) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	s.calls++
+	prefix, delimiter := aws.ToString(params.Prefix), aws.ToString(params.Delimiter)
+	var results []string
+	seen := make(map[string]bool)
+	for _, v := range s.keys {
+		if !strings.HasPrefix(v, prefix) {
+			continue
+		}
+		if index := strings.Index(v[len(prefix):], delimiter); delimiter != "" && index >= 0 {
+			v = v[:len(prefix)+index+1]
+		}
+		if !seen[v] {
+			seen[v] = true
+			results = append(results, v)
+		}
+	}
+	start, _ := strconv.Atoi(aws.ToString(params.ContinuationToken))
+	end := min(start+s.pageSize, len(results))
+	out := &s3.ListObjectsV2Output{}
+	for _, v := range results[start:end] {
+		if strings.HasSuffix(v, "/") && delimiter != "" {
+			out.CommonPrefixes = append(out.CommonPrefixes, s3types.CommonPrefix{Prefix: aws.String(v)})
+		} else {
+			out.Contents = append(out.Contents, s3types.Object{Key: aws.String(v), Size: aws.Int64(int64(len(v)))})
+		}
+	}
+	if end < len(results) {
+		out.IsTruncated = aws.Bool(true)
+		out.NextContinuationToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func (s *s3Store) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *s3Store) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *s3Store) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// ssmStore is an ssmloader.Client describing its parameters pageSize at a time.
+type ssmStore struct {
+	names    []string
+	pageSize int
+	calls    int
+}
+
+func (s *ssmStore) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *ssmStore) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *ssmStore) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	s.calls++
+	var results []string
+	for _, v := range s.names {
+		match := true
+		for _, f := range params.ParameterFilters {
+			if aws.ToString(f.Option) == "BeginsWith" && !strings.HasPrefix(v, f.Values[0]) {
+				match = false
+			}
+		}
+		if match {
+			results = append(results, v)
+		}
+	}
+	start, _ := strconv.Atoi(aws.ToString(params.NextToken))
+	end := min(start+s.pageSize, len(results))
+	out := &ssm.DescribeParametersOutput{}
+	for _, v := range results[start:end] {
+		out.Parameters = append(out.Parameters, types.ParameterMetadata{Name: aws.String(v), Version: 1})
+	}
+	if end < len(results) {
+		out.NextToken = aws.String(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func (s *ssmStore) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (s *ssmStore) DeleteParameter(ctx context.Context, params *ssm.DeleteParameterInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParameterOutput, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// treeEntries returns the GitHub tree of keys below dir, recursively or a single level deep.
+func treeEntries(keys []string, dir string, recursive bool) string {
+	var entries []string
+	seen := make(map[string]bool)
+	for _, v := range keys {
+		if !strings.HasPrefix(v, dir) {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(v, dir), "/")
+		for i := range parts {
+			if i > 0 && !recursive {
+				break
+			}
+			name := strings.Join(parts[:i+1], "/")
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			kind := "blob"
+			if i < len(parts)-1 {
+				kind = "tree"
+			}
+			entries = append(entries, fmt.Sprintf(`{"path":%q,"type":%q,"sha":%q}`, name, kind, dir+name))
+		}
+	}
+	return `{"tree":[` + strings.Join(entries, ",") + `],"truncated":false}`
+}
+
+func TestWalkPages(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"a.yaml", "b.yaml", "c.yaml", "d.yaml", "e.yaml"}
+	s3s := &s3Store{keys: keys, pageSize: 2}
+	list, err := s3loader.List(ctx, s3s, "s3://b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(list, keys) || s3s.calls != 3 {
+		t.Fatal("invalid s3 pages", list, s3s.calls)
+	}
+	names := make([]string, len(keys))
+	for i, v := range keys {
+		names[i] = "/app/" + v
+	}
+	ssms := &ssmStore{names: names, pageSize: 2}
+	if list, err = ssmloader.List(ctx, ssms, "ssm://app"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(list, keys) || ssms.calls != 3 {
+		t.Fatal("invalid ssm pages", list, ssms.calls)
+	}
+
+	// cancelling the context stops the walk before the next page
+	for name, walk := range map[string]func(ctx context.Context, fn fileloaders.WalkFunc) error{
+		"s3": func(ctx context.Context, fn fileloaders.WalkFunc) error {
+			return s3loader.Walk(ctx, s3s, "s3://b/", fn)
+		},
+		"ssm": func(ctx context.Context, fn fileloaders.WalkFunc) error {
+			return ssmloader.Walk(ctx, ssms, "ssm://app", fn)
+		},
+	} {
+		s3s.calls, ssms.calls = 0, 0
+		ctx, cancel := context.WithCancel(ctx)
+		count := 0
+		err = walk(ctx, func(entry fileloaders.Entry) error {
+			count++
+			cancel()
+			return nil
+		})
+		cancel()
+		if !errors.Is(err, context.Canceled) || count != 2 || s3s.calls+ssms.calls != 1 {
+			t.Fatal("expected the walk to stop", name, err, count)
+		}
+	}
+
+	// a truncated recursive tree is walked again one level at a time
+	levels := 0
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recursive := r.URL.Query().Get("recursive") != ""
+		switch strings.TrimPrefix(r.URL.Path, "/repos/goccha/fileloaders/git/trees/") {
+		case "main":
+			if recursive {
+				_, _ = fmt.Fprint(w, `{"tree":[{"path":"a.yaml","type":"blob","sha":"a"}],"truncated":true}`)
+				return
+			}
+			levels++
+			_, _ = fmt.Fprint(w, `{"tree":[{"path":"a.yaml","type":"blob","sha":"a"},{"path":"dir","type":"tree","sha":"dir"}],"truncated":false}`)
+		case "dir":
+			_, _ = fmt.Fprint(w, `{"tree":[{"path":"b.yaml","type":"blob","sha":"b"},{"path":"sub","type":"tree","sha":"sub"},{"path":"sub/c.yaml","type":"blob","sha":"c"},{"path":"d#1?50%.yaml","type":"blob","sha":"d"}],"truncated":false}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	gh := github.NewClient(nil)
+	if gh.BaseURL, err = url.Parse(ts.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := githubloader.ListEntries(ctx, gh, "github://goccha/fileloaders/main", fileloaders.WithRecursive(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list = fileloaders.EntryNames(entries); !slices.Equal(list, []string{"a.yaml", "dir", "dir/b.yaml", "dir/sub", "dir/sub/c.yaml", "dir/d#1?50%.yaml"}) || levels != 1 {
+		t.Fatal("invalid truncated tree", list, levels)
+	}
+	// reserved characters of the names survive the round trip through the entry URI
+	file := fileloaders.Parse(entries[5].URI)
+	if file.Path != "fileloaders/dir/d#1?50%.yaml" || file.URI().Get("ref") != "main" {
+		t.Fatal("invalid entry uri", entries[5].URI, file.Path)
+	}
+}
+
+func TestListDirectory(t *testing.T) {
+	keys := []string{"configs/app.yaml", "configs/sub/db.yaml", "configs-old/app.yaml"}
+	root := t.TempDir()
+	for _, v := range keys {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(v)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, v), []byte(v), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/storage/v1/b/b/o":
+			// the JSON API of GCS
+			prefix, delimiter := r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter")
+			var items, prefixes []string
+			seen := make(map[string]bool)
+			for _, v := range keys {
+				if !strings.HasPrefix(v, prefix) {
+					continue
+				}
+				if index := strings.Index(v[len(prefix):], delimiter); delimiter != "" && index >= 0 {
+					if p := v[:len(prefix)+index+1]; !seen[p] {
+						seen[p] = true
+						prefixes = append(prefixes, strconv.Quote(p))
+					}
+					continue
+				}
+				items = append(items, fmt.Sprintf(`{"name":%q,"bucket":"b","size":"%d","generation":"1"}`, v, len(v)))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, `{"kind":"storage#objects","items":[`+strings.Join(items, ",")+`],"prefixes":[`+strings.Join(prefixes, ",")+`]}`)
+		case strings.HasPrefix(r.URL.Path, "/repos/goccha/fileloaders/git/trees/main:"):
+			dir := strings.TrimPrefix(r.URL.Path, "/repos/goccha/fileloaders/git/trees/main:") + "/"
+			_, _ = fmt.Fprint(w, treeEntries(keys, dir, r.URL.Query().Get("recursive") != ""))
+		case strings.HasPrefix(r.URL.Path, "/www/"):
+			// an autoindex page
+			dir := strings.TrimPrefix(r.URL.Path, "/www/")
+			var links []string
+			seen := make(map[string]bool)
+			for _, v := range keys {
+				if !strings.HasPrefix(v, dir) {
+					continue
+				}
+				name, _, isDir := strings.Cut(strings.TrimPrefix(v, dir), "/")
+				if isDir {
+					name += "/"
+				}
+				if !seen[name] {
+					seen[name] = true
+					links = append(links, `<a href="`+name+`">`+name+`</a>`)
+				}
+			}
+			_, _ = fmt.Fprint(w, `<a href="../">../</a>`+strings.Join(links, "\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	ctx := context.Background()
+	gs, err := storage.NewClient(ctx, option.WithEndpoint(ts.URL+"/storage/v1/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = gs.Close()
+	}()
+	gh := github.NewClient(nil)
+	if gh.BaseURL, err = url.Parse(ts.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	ssmNames := make([]string, len(keys))
+	for i, v := range keys {
+		ssmNames[i] = "/" + v
+	}
+	m := fileloaders.New(
+		s3loader.With(&s3Store{keys: keys, pageSize: 1000}),
+		gsloader.With(gs),
+		ssmloader.With(&ssmStore{names: ssmNames, pageSize: 50}),
+		githubloader.WithClient(gh),
+		httploader.With(http.DefaultClient, httploader.WithListing(httploader.ListingAutoIndex)),
+	)
+	ctx = fileloaders.NewContext(ctx, m)
+	for _, path := range []string{
+		"file://" + filepath.ToSlash(root) + "/configs",
+		"s3://b/configs",
+		"gs://b/configs",
+		"ssm://configs",
+		"github://goccha/fileloaders/main/configs",
+		ts.URL + "/www/configs",
+	} {
+		list, err := fileloaders.List(ctx, path)
+		if err != nil {
+			t.Fatal(path, err)
+		}
+		slices.Sort(list)
+		if !slices.Equal(list, []string{"app.yaml", "sub"}) {
+			t.Error("invalid list", path, list)
+		}
+		var files []string
+		if err = fileloaders.Walk(ctx, path, func(entry fileloaders.Entry) error {
+			if !entry.IsDir() {
+				files = append(files, entry.Name)
+			}
+			return nil
+		}, fileloaders.WithRecursive(true)); err != nil {
+			t.Fatal(path, err)
+		}
+		slices.Sort(files)
+		if !slices.Equal(files, []string{"app.yaml", "sub/db.yaml"}) {
+			t.Error("invalid recursive walk", path, files)
+		}
+	}
+	for _, pattern := range []string{"s3://b/configs*/app.yaml", "gs://b/configs*/app.yaml"} {
+		list, err := fileloaders.Glob(ctx, pattern)
+		if err != nil {
+			t.Fatal(pattern, err)
+		}
+		slices.Sort(list)
+		scheme := pattern[:strings.Index(pattern, ":")]
+		if !slices.Equal(list, []string{scheme + "://b/configs-old/app.yaml", scheme + "://b/configs/app.yaml"}) {
+			t.Error("invalid prefix glob", pattern, list)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	yamldecoder.Register()
+	tomldecoder.Register()
+	dotenvdecoder.Register()
+	inidecoder.Register()
+	ctx := context.Background()
+	dir := t.TempDir()
+	type config struct {
+		Name string `json:"name" yaml:"name" toml:"name" ini:"name" xml:"name"`
+	}
+	for name, body := range map[string]string{
+		"app.json": `{"name":"app"}`,
+		"app.xml":  `<config><name>app</name></config>`,
+		"app.yaml": "name: app\n",
+		"app.toml": `name = "app"`,
+		"app.env":  "name=app\n",
+		"app.ini":  "name = app\n",
+	} {
+		path := dir + "/" + name
+		if _, err := fileloaders.Save(ctx, path, strings.NewReader(body)); err != nil {
+			t.Fatal(err)
+		}
+		file, err := fileloaders.Load(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c config
+		if err = file.Decode(&c); err != nil {
+			t.Fatal(name, err)
+		}
+		if c.Name != "app" {
+			t.Fatal("invalid decode", name)
+		}
+	}
+}
+
+func TestLoadMerged(t *testing.T) {
+	yamldecoder.Register()
+	ctx := context.Background()
+	dir := t.TempDir()
+	defaults := "file://" + dir + "/defaults.json"
+	prod := "file://" + dir + "/prod.yaml"
+	if _, err := fileloaders.Save(ctx, defaults, strings.NewReader(`{"db":{"host":"localhost","port":5432},"services":[{"name":"a","replicas":1}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileloaders.Save(ctx, prod, strings.NewReader("db:\n  host: db.prod\nservices:\n  - name: a\n    replicas: 3\n  - name: b\n")); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := fileloaders.NewMerger(fileloaders.WithMergeKey("name")).Load(ctx, defaults, prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c struct {
+		DB struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		} `json:"db"`
+		Services []struct {
+			Name     string `json:"name"`
+			Replicas int    `json:"replicas"`
+		} `json:"services"`
+	}
+	if err = merged.Unmarshal(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.Host != "db.prod" || c.DB.Port != 5432 || len(c.Services) != 2 || c.Services[0].Replicas != 3 {
+		t.Fatal("invalid merge", c)
+	}
+	if v, _ := merged.Source("db.port"); v != defaults {
+		t.Fatal("invalid source", v)
+	}
+	if v, _ := merged.Source("services[0].replicas"); v != prod {
+		t.Fatal("invalid source", v)
+	}
+}
+
 func TestHttp(t *testing.T) {
 	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/README.md" {
-			_, _ = fmt.Fprint(w, "# README")
-		} else {
+		if r.URL.Path == "/README.md" {
+			_, _ = fmt.Fprint(w, "# README")
+		} else if r.URL.Path == "/private.json" {
+			_, _ = fmt.Fprint(w, r.Header.Get("Authorization")+" "+r.Header.Get("X-Tenant"))
+		} else if r.URL.Path == "/admin.json" {
+			if r.Header.Get("Authorization") != "Bearer admin" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = fmt.Fprint(w, "secret")
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	// 別goroutine上でリッスンが開始される
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	fileloaders.Setup(httploader.With(http.DefaultClient))
+
+	file, err := fileloaders.Load(context.Background(), ts.URL+"/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "# README" {
+		t.Fatal("invalid load")
+	}
+
+	r, _, err := fileloaders.Open(context.Background(), ts.URL+"/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	if body, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	} else if string(body) != "# README" {
+		t.Fatal("invalid open")
+	}
+
+	_, err = fileloaders.Load(context.Background(), ts.URL+"/NOTFOUND.md")
+	if !errors.Is(err, fileloaders.ErrNotExist) {
+		t.Fatal(err)
+	}
+	var loadErr *fileloaders.LoadError
+	var statusErr *httploader.StatusError
+	if !errors.As(err, &loadErr) || loadErr.Scheme != "http" || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatal(err)
+	}
+
+	loader := httploader.New(http.DefaultClient, httploader.WithHeader("X-Tenant", "acme"), httploader.WithBearerToken("token"))
+	file, err = loader.Load(context.Background(), ts.URL+"/private.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "Bearer token acme" {
+		t.Fatal("invalid headers", string(file.GetBody()))
+	}
+	host := strings.TrimPrefix(ts.URL, "http://")
+	file, err = loader.Load(context.Background(), ts.URL+"/private.json", httploader.WithCredentials(func(ctx context.Context, h string) (string, error) {
+		if h != host {
+			return "", nil
+		}
+		return "Basic c2VjcmV0", nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "Basic c2VjcmV0 acme" {
+		t.Fatal("invalid credentials", string(file.GetBody()))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = loader.Load(ctx, ts.URL+"/private.json"); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected canceled", err)
+	}
+
+	// loads with different credentials must not share the cached copy
+	cache := fileloaders.NewCachingLoader(httploader.New(http.DefaultClient))
+	file, err = cache.Load(context.Background(), ts.URL+"/admin.json", httploader.WithBearerToken("admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "secret" {
+		t.Fatal("invalid admin load", string(file.GetBody()))
+	}
+	if _, err = cache.Load(context.Background(), ts.URL+"/admin.json", httploader.WithBearerToken("guest")); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatal("expected forbidden with another token", err)
+	}
+	if _, err = cache.Load(context.Background(), ts.URL+"/admin.json"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatal("expected forbidden without token", err)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	ctx := context.Background()
+	failing := false
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"name":"cached"}`)
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	store, err := fileloaders.NewDiskStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := ts.URL + "/config.json"
+	cache := fileloaders.NewCachingLoader(httploader.New(http.DefaultClient), fileloaders.WithTTL(0), fileloaders.WithCacheStore(store))
+	file, err := cache.Load(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Stale() {
+		t.Fatal("unexpected stale file")
+	}
+
+	failing = true
+	if _, err = cache.Load(ctx, path); err == nil {
+		t.Fatal("expected error without stale-if-error")
+	}
+	// a new loader reads the entry written by the previous one, as after a restart
+	cache = fileloaders.NewCachingLoader(httploader.New(http.DefaultClient), fileloaders.WithTTL(0), fileloaders.WithCacheStore(store), fileloaders.WithStaleIfError(0))
+	file, err = cache.Load(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !file.Stale() || string(file.GetBody()) != `{"name":"cached"}` {
+		t.Fatal("expected stale file")
+	}
+	if file.StaleError() == nil {
+		t.Fatal("missing stale error")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"enabled":false}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, err := fileloaders.Watch(ctx, "file://"+path, fileloaders.WithInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fileloaders.SaveFile(ctx, path, strings.NewReader(`{"enabled":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	event := <-events
+	if event.Kind != fileloaders.EventChanged || string(event.File.GetBody()) != `{"enabled":true}` {
+		t.Fatal("invalid file poll event", event.Kind, event.Err)
+	}
+
+	events, err = fsnotifywatcher.Watch(ctx, "file://"+path, fileloaders.WithDebounce(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fileloaders.SaveFile(ctx, path, strings.NewReader(`{"enabled":false}`)); err != nil {
+		t.Fatal(err)
+	}
+	event = <-events
+	if event.Kind != fileloaders.EventChanged || string(event.File.GetBody()) != `{"enabled":false}` {
+		t.Fatal("invalid fsnotify event", event.Kind, event.Err)
+	}
+
+	var mu sync.Mutex
+	etag := `"v1"`
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("ETag", etag)
+		_, _ = fmt.Fprint(w, etag)
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	events, err = fileloaders.Poll(ctx, httploader.New(http.DefaultClient), ts.URL+"/flags.json",
+		fileloaders.WithInterval(20*time.Millisecond), fileloaders.WithJitter(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	etag = `"v2"`
+	mu.Unlock()
+	event = <-events
+	if event.Kind != fileloaders.EventChanged || string(event.File.GetBody()) != `"v2"` {
+		t.Fatal("invalid poll event", event.Kind, event.Err)
+	}
+}
+
+func TestReloadable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	type Flags struct {
+		Enabled bool `json:"enabled"`
+		Limit   int  `json:"limit"`
+	}
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"enabled":false,"limit":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	flags, err := fileloaders.NewReloadable[Flags](ctx, "file://"+path, fileloaders.WithValidator(func(v *Flags) error {
+		if v.Limit <= 0 {
+			return errors.New("limit must be positive")
+		}
+		return nil
+	}), fileloaders.WithReloadWatch[Flags](fileloaders.WithInterval(20*time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags.Get().Enabled || flags.LastReload().IsZero() {
+		t.Fatal("invalid initial value")
+	}
+	changed := make(chan *Flags, 1)
+	flags.OnChange(func(old, new *Flags) {
+		changed <- new
+	})
+
+	if _, err = fileloaders.SaveFile(ctx, path, strings.NewReader(`{"enabled":true,"limit":0}`)); err != nil {
+		t.Fatal(err)
+	}
+	for flags.LastError() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if flags.Get().Enabled {
+		t.Fatal("invalid update was accepted")
+	}
+
+	if _, err = fileloaders.SaveFile(ctx, path, strings.NewReader(`{"enabled":true,"limit":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if v := <-changed; !v.Enabled || flags.Get() != v || flags.LastError() != nil {
+		t.Fatal("invalid reloaded value")
+	}
+}
+
+func TestLoadFirst(t *testing.T) {
+	ctx := context.Background()
+	file, err := fileloaders.LoadFirst(ctx, "./not_found.json", "../README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source, ok := file.Source(); !ok || source != "../README.md" {
+		t.Fatal("invalid source", source)
+	}
+	if _, err = fileloaders.LoadFirst(ctx, "./not_found.json", "./not_found.yaml"); !errors.Is(err, fileloaders.ErrNotExist) {
+		t.Fatal(err)
+	}
+
+	override, defaults := t.TempDir(), t.TempDir()
+	if err = os.WriteFile(filepath.Join(defaults, "app.json"), []byte(`{"name":"default"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loader := fileloaders.New(fileloaders.WithFallback("cfg", []string{"file://" + override + "/", "file://" + defaults + "/"}))
+	file, err = loader.Load(ctx, "cfg://app.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source, _ := file.Source(); source != "file://"+defaults+"/app.json" || string(file.GetBody()) != `{"name":"default"}` {
+		t.Fatal("invalid fallback", source)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	prefixes := []string{ts.URL + "/", "file://" + defaults + "/"}
+	loader = fileloaders.New(httploader.With(http.DefaultClient), fileloaders.WithFallback("cfg", prefixes))
+	if _, err = loader.Load(ctx, "cfg://app.json"); !errors.Is(err, fileloaders.ErrTransient) {
+		t.Fatal("expected the unavailable backend to fail", err)
+	}
+	loader = fileloaders.New(httploader.With(http.DefaultClient), fileloaders.WithFallback("cfg", prefixes, fileloaders.WithSkipTransient(true)))
+	if file, err = loader.Load(ctx, "cfg://app.json"); err != nil {
+		t.Fatal(err)
+	}
+	if source, _ := file.Source(); source != "file://"+defaults+"/app.json" {
+		t.Fatal("expected the unavailable backend to be skipped", source)
+	}
+	mctx := fileloaders.NewContext(ctx, fileloaders.New(httploader.With(http.DefaultClient)))
+	paths := []string{ts.URL + "/app.json", "file://" + defaults + "/app.json"}
+	if _, err = fileloaders.LoadFirst(mctx, paths...); !errors.Is(err, fileloaders.ErrTransient) {
+		t.Fatal("expected LoadFirst to stop at the unavailable backend", err)
+	}
+	if file, err = fileloaders.LoadFirstWith(mctx, paths, fileloaders.WithSkipTransient(true)); err != nil {
+		t.Fatal(err)
+	}
+	if source, _ := file.Source(); source != paths[1] {
+		t.Fatal("expected LoadFirstWith to skip the unavailable backend", source)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts[r.URL.Path]++
+		n := attempts[r.URL.Path]
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/missing.json":
+			w.WriteHeader(http.StatusNotFound)
+		case n < 3:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = fmt.Fprint(w, `{"ok":true}`)
+		}
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	loader := fileloaders.New(httploader.With(http.DefaultClient),
+		fileloaders.WithMiddleware(fileloaders.Retry(fileloaders.WithMaxAttempts(3), fileloaders.WithBackoff(time.Millisecond, 10*time.Millisecond))))
+
+	file, err := loader.Load(ctx, ts.URL+"/flaky.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != `{"ok":true}` || attempts["/flaky.json"] != 3 {
+		t.Fatal("invalid retry", attempts)
+	}
+	if _, err = loader.Load(ctx, ts.URL+"/missing.json"); !errors.Is(err, fileloaders.ErrNotExist) || attempts["/missing.json"] != 1 {
+		t.Fatal("not found must not be retried", err, attempts)
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	hits := map[string]int{}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/missing.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprint(w, r.URL.Path)
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	loader := fileloaders.New(httploader.With(http.DefaultClient))
+	var paths []string
+	for i := 0; i < 20; i++ {
+		paths = append(paths, fmt.Sprintf("%s/tenant%d.json", ts.URL, i%10))
+	}
+	files, err := loader.LoadAll(ctx, append(paths, "../README.md"), fileloaders.WithConcurrency(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 11 || string(files[ts.URL+"/tenant3.json"].GetBody()) != "/tenant3.json" || hits["/tenant3.json"] != 1 {
+		t.Fatal("invalid load all", len(files), hits)
+	}
+
+	files, err = loader.LoadAll(ctx, []string{ts.URL + "/missing.json", ts.URL + "/tenant1.json"})
+	var batchErr *fileloaders.BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errors[ts.URL+"/missing.json"], fileloaders.ErrNotExist) || len(files) != 1 {
+		t.Fatal("invalid best-effort load all", err)
+	}
+
+	backend := &batchBackend{release: make(chan struct{}), flaky: map[string]bool{"kv://k5": true}}
+	wrapped := fileloaders.New()
+	wrapped.Register("kv", backend)
+	wrapped.Use(func(loader fileloaders.Loader) fileloaders.Loader {
+		return fileloaders.NewCachingLoader(loader)
+	}, fileloaders.Retry(fileloaders.WithBackoff(time.Millisecond, time.Millisecond)))
+	paths = nil
+	for i := 0; i < 6; i++ {
+		paths = append(paths, fmt.Sprintf("kv://k%d", i))
+	}
+	go func() {
+		// every chunk of two paths waits until three of them run at the same time
+		for backend.waiting() < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		close(backend.release)
+	}()
+	files, err = wrapped.LoadAll(ctx, paths, fileloaders.WithConcurrency(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 6 || string(files["kv://k5"].GetBody()) != "kv://k5" || backend.calls != 4 {
+		t.Fatal("invalid wrapped batch", len(files), backend.calls)
+	}
+	if files, err = wrapped.LoadAll(ctx, paths); err != nil || len(files) != 6 || backend.calls != 4 {
+		t.Fatal("expected cached batch", err, backend.calls)
+	}
+}
+
+// batchBackend is a BatchLoader that fails its flaky paths once with a transient error.
+type batchBackend struct {
+	mu      sync.Mutex
+	calls   int
+	active  int
+	release chan struct{}
+	flaky   map[string]bool
+}
+
+func (b *batchBackend) waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active
+}
+
+func (b *batchBackend) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return nil, fileloaders.ErrNotSupported
+}
+
+func (b *batchBackend) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	return nil, fileloaders.ErrNotSupported
+}
+
+func (b *batchBackend) BatchSize() int {
+	return 2
+}
+
+func (b *batchBackend) LoadBatch(ctx context.Context, paths []string, opt ...fileloaders.LoaderOption) (map[string]*fileloaders.File, error) {
+	b.mu.Lock()
+	b.calls++
+	b.active++
+	b.mu.Unlock()
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	files := make(map[string]*fileloaders.File)
+	errs := make(map[string]error)
+	for _, v := range paths {
+		if b.flaky[v] {
+			delete(b.flaky, v)
+			errs[v] = fileloaders.NewLoadError("kv", v, fileloaders.ErrTransient, errors.New("unavailable"))
+			continue
+		}
+		files[v] = fileloaders.Parse(v).WriteBody([]byte(v))
+	}
+	if len(errs) > 0 {
+		return files, &fileloaders.BatchError{Errors: errs}
+	}
+	return files, nil
+}
+
+// waitingContext reports when a caller starts waiting on Done.
+type waitingContext struct {
+	context.Context
+	waiting chan<- struct{}
+	once    sync.Once
+}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(func() {
+		c.waiting <- struct{}{}
+	})
+	return c.Context.Done()
+}
+
+func TestDedup(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	hits := 0
+	release := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		<-release
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = fmt.Fprint(w, "shared")
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	loader := fileloaders.New(httploader.With(http.DefaultClient), fileloaders.WithDedup())
+	etag := `"v1"`
+	prev := fileloaders.Parse(ts.URL + "/shared.json").Add(fileloaders.WithETag(&etag))
+
+	waiting := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, 13)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			file, err := loader.Load(&waitingContext{Context: ctx, waiting: waiting}, ts.URL+"/shared.json")
+			if err == nil && string(file.GetBody()) != "shared" {
+				err = errors.New("invalid body")
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := loader.Load(&waitingContext{Context: ctx, waiting: waiting}, ts.URL+"/shared.json", fileloaders.IfChanged(prev))
+			if !errors.Is(err, fileloaders.ErrNotModified) {
+				err = fmt.Errorf("expected not modified: %v", err)
+			} else {
+				err = nil
+			}
+			errs <- err
+		}()
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	canceledErr := make(chan error, 1)
+	go func() {
+		_, err := loader.Load(&waitingContext{Context: canceled, waiting: waiting}, ts.URL+"/shared.json")
+		canceledErr <- err
+	}()
+	for i := 0; i < 13; i++ {
+		<-waiting
+	}
+	cancel()
+	if err := <-canceledErr; !errors.Is(err, context.Canceled) {
+		t.Fatal("expected cancel", err)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits != 2 {
+		t.Fatal("expected one request per distinct options", hits)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "registry")
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	loader := fileloaders.New()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			loader.Register("http", httploader.New(http.DefaultClient))
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = loader.Load(ctx, ts.URL+"/registry.json")
+		}()
+	}
+	wg.Wait()
+	if schemes := loader.Schemes(); len(schemes) != 1 || schemes[0] != "http" {
+		t.Fatal("invalid schemes", schemes)
+	}
+	if _, ok := loader.Lookup("http"); !ok {
+		t.Fatal("http loader is not registered")
+	}
+
+	file, err := fileloaders.Load(fileloaders.NewContext(ctx, loader), ts.URL+"/registry.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "registry" || fileloaders.FromContext(ctx) != fileloaders.Default() {
+		t.Fatal("invalid context loader")
+	}
+
+	loader.Unregister("http")
+	if _, err = loader.Load(ctx, ts.URL+"/registry.json"); !errors.Is(err, fileloaders.ErrNotSupported) {
+		t.Fatal(err)
+	}
+
+	// a cached fallback stays bound to its MapLoader, and caching twice keeps the first cache
+	store := &prefixStore{keys: []string{"defaults/app.json"}}
+	cached := fileloaders.New(fileloaders.WithFallback("cfg", []string{"mem://b/override/", "mem://b/defaults/"}),
+		fileloaders.WithCache(fileloaders.WithTTL(0)), fileloaders.WithCache())
+	cached.Register("mem", store)
+	for i := 0; i < 2; i++ {
+		if file, err = cached.Load(ctx, "cfg://app.json"); err != nil || string(file.GetBody()) != "defaults/app.json" {
+			t.Fatal("invalid cached fallback", err)
+		}
+	}
+	if store.loads != 2 {
+		t.Fatal("expected a single cache layer", store.loads)
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := fileloaders.ParseURI("s3://user:secret@bucket/dir/a%3Fb%20c.json?version=3&region=ap-northeast-1#section")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "s3" || u.Bucket != "bucket" || u.Key != "dir/a?b c.json" || u.Get("version") != "3" || u.Get("region") != "ap-northeast-1" || u.Fragment != "section" {
+		t.Fatal("invalid uri", u)
+	}
+	if password, _ := u.User.Password(); u.User.Username() != "user" || password != "secret" {
+		t.Fatal("invalid credentials")
+	}
+	if v := u.String(); v != "s3://user:secret@bucket/dir/a%3Fb%20c.json?region=ap-northeast-1&version=3#section" {
+		t.Fatal("invalid string", v)
+	}
+	file := fileloaders.Parse("github://goccha/fileloaders/README.md?ref=main")
+	if file.Bucket != "goccha" || file.Path != "fileloaders/README.md" || file.URI().Get("ref") != "main" {
+		t.Fatal("invalid file uri", file.Path)
+	}
+	file = fileloaders.Parse("s3://b/50%off.txt?version=1")
+	if file.Bucket != "b" || file.Path != "50%off.txt" || file.URI().Get("version") != "1" {
+		t.Fatal("invalid fallback uri", file.Path, file.URI().Query)
+	}
+	file = fileloaders.Parse("s3://b/file#1.txt?version=2")
+	if file.Path != "file#1.txt" || file.URI().Get("version") != "2" {
+		t.Fatal("invalid key with #", file.Path, file.URI().Query)
+	}
+	file = fileloaders.Parse("s3://b/50%off#1.txt")
+	if file.Path != "50%off#1.txt" {
+		t.Fatal("invalid fallback key with #", file.Path)
+	}
+}
+
+func TestHttpConditional(t *testing.T) {
+	ctx := context.Background()
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	downloads := 0
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(http.StatusNonAuthoritativeInfo)
+		_, _ = fmt.Fprint(w, "name: conditional\n")
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	loader := httploader.New(http.DefaultClient)
+	file, err := loader.Load(ctx, ts.URL+"/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := file.Hash()
+	contentType, _ := file.ContentType()
+	if t2, ok := file.ModTime(); !ok || !t2.Equal(modTime) || hash != `"v1"` || contentType != "application/yaml" {
+		t.Fatal("invalid response metadata", hash, contentType, t2)
+	}
+	if _, err = loader.Load(ctx, ts.URL+"/config", fileloaders.IfChanged(file)); !errors.Is(err, fileloaders.ErrNotModified) {
+		t.Fatal("expected not modified", err)
+	}
+
+	cache := fileloaders.NewCachingLoader(loader, fileloaders.WithTTL(0))
+	for i := 0; i < 3; i++ {
+		if file, err = cache.Load(ctx, ts.URL+"/cached"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if downloads != 2 || string(file.GetBody()) != "name: conditional\n" {
+		t.Fatal("expected a single cached download", downloads)
+	}
+	// revalidating must not write into the spare capacity of the caller's options
+	opts := make([]fileloaders.LoaderOption, 1, 2)
+	opts[0] = fileloaders.WithRecursive(false)
+	if _, err = cache.Load(ctx, ts.URL+"/cached", opts...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cache.Load(ctx, ts.URL+"/cached", opts...); err != nil {
+		t.Fatal(err)
+	}
+	if opts[:2][1] != nil {
+		t.Fatal("caller options modified")
+	}
+}
+
+func TestHttpList(t *testing.T) {
+	ctx := context.Background()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PROPFIND" && r.Header.Get("Depth") == "1":
+			w.WriteHeader(http.StatusMultiStatus)
+			_, _ = fmt.Fprint(w, `<?xml version="1.0"?><D:multistatus xmlns:D="DAV:">`+
+				`<D:response><D:href>/dav/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/></D:resourcetype></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`+
+				`<D:response><D:href>/dav/app.yaml</D:href><D:propstat><D:prop><D:resourcetype/><D:getcontentlength>12</D:getcontentlength><D:getetag>"e1"</D:getetag></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`+
+				`<D:response><D:href>/dav/conf.d/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/></D:resourcetype></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`+
+				`</D:multistatus>`)
+		case r.URL.Path == "/autoindex/":
+			_, _ = fmt.Fprint(w, `<html><body><a href="?C=N;O=D">Name</a><a href="../">Parent Directory</a>`+
+				`<a href="app.yaml">app.yaml</a><a href="conf.d/">conf.d/</a><a href="it&#8217;s&#x20;a&nbsp;file.txt">x</a><a href="https://example.com/">x</a></body></html>`)
+		case r.URL.Path == "/autoindex/conf.d/":
+			_, _ = fmt.Fprint(w, `<pre><a href="../">../</a><a href="db.yaml">db.yaml</a></pre>`)
+		case r.URL.Path == "/manifest/index.json":
+			_, _ = fmt.Fprint(w, `["app.yaml", {"name": "db.yaml", "size": 7, "hash": "h1"}, {"name": "conf.d", "dir": true}]`)
+		case r.URL.Path == "/custom/files.json":
+			_, _ = fmt.Fprint(w, `{"data": {"items": [{"path": "app.yaml", "bytes": 3}]}}`)
+		default:
 			w.WriteHeader(http.StatusNotFound)
 		}
 	})
-	// 別goroutine上でリッスンが開始される
 	ts := httptest.NewServer(h)
 	defer ts.Close()
-	fileloaders.Setup(httploader.With(http.DefaultClient))
 
-	file, err := fileloaders.Load(context.Background(), ts.URL+"/README.md")
+	if _, err := httploader.List(ctx, http.DefaultClient, ts.URL+"/autoindex/"); !errors.Is(err, fileloaders.ErrNotSupported) {
+		t.Fatal("expected not supported", err)
+	}
+	list, err := httploader.List(ctx, http.DefaultClient, ts.URL+"/autoindex", httploader.WithListing(httploader.ListingAutoIndex), fileloaders.WithRecursive(true))
 	if err != nil {
 		t.Fatal(err)
 	}
-	if string(file.GetBody()) != "# README" {
-		t.Fatal("invalid load")
+	if strings.Join(list, ",") != "app.yaml,conf.d,conf.d/db.yaml,it\u2019s a\u00a0file.txt" {
+		t.Fatal("invalid autoindex listing", list)
+	}
+
+	entries, err := httploader.ListEntries(ctx, http.DefaultClient, ts.URL+"/manifest/", httploader.WithListing(httploader.ListingManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[1].Size != 7 || entries[1].Hash != "h1" || !entries[2].IsDir() || entries[0].URI != ts.URL+"/manifest/app.yaml" {
+		t.Fatal("invalid manifest listing", entries)
+	}
+	schema := httploader.ManifestSchema{Entries: "data.items", Name: "path", Size: "bytes"}
+	entries, err = httploader.ListEntries(ctx, http.DefaultClient, ts.URL+"/custom/", httploader.WithManifest("files.json", schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "app.yaml" || entries[0].Size != 3 {
+		t.Fatal("invalid custom manifest listing", entries)
+	}
+
+	fileloaders.Setup(httploader.With(http.DefaultClient, httploader.WithListing(httploader.ListingWebDAV)))
+	entries, err = fileloaders.ListEntries(ctx, ts.URL+"/dav/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "app.yaml" || entries[0].Size != 12 || entries[0].Hash != `"e1"` || !entries[1].IsDir() {
+		t.Fatal("invalid webdav listing", entries)
+	}
+}
+
+func TestRange(t *testing.T) {
+	ctx := context.Background()
+	file, err := fileloaders.Load(ctx, "file://../README.md", fileloaders.WithRange(2, 11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "fileloaders" || file.Offset() != 2 {
+		t.Fatal("invalid file range", string(file.GetBody()))
+	}
+
+	body := strings.Repeat("0123456789", 100)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	requests := 0
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/ranged":
+			w.Header().Set("ETag", `"r1"`)
+			http.ServeContent(w, r, "ranged", modTime, strings.NewReader(body))
+		case "/plain":
+			_, _ = fmt.Fprint(w, body)
+		case "/flaky":
+			// the first response is cut off after 300 bytes
+			w.Header().Set("ETag", `"f1"`)
+			if r.Header.Get("Range") == "" {
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				_, _ = fmt.Fprint(w, body[:300])
+				return
+			}
+			http.ServeContent(w, r, "flaky", modTime, strings.NewReader(body))
+		}
+	})
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	loader := httploader.New(http.DefaultClient)
+	for _, path := range []string{"/ranged", "/plain"} {
+		file, err = loader.Load(ctx, ts.URL+path, fileloaders.WithRange(995, 10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(file.GetBody()) != "56789" || file.Offset() != 995 {
+			t.Fatal("invalid http range", path, string(file.GetBody()))
+		}
+		file, err = loader.Load(ctx, ts.URL+path, fileloaders.WithRange(-3, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(file.GetBody()) != "789" || file.Offset() != 997 {
+			t.Fatal("invalid http suffix range", path, string(file.GetBody()))
+		}
+	}
+
+	cache := fileloaders.NewCachingLoader(loader)
+	if _, err = cache.Load(ctx, ts.URL+"/ranged"); err != nil {
+		t.Fatal(err)
+	}
+	if file, err = cache.Load(ctx, ts.URL+"/ranged", fileloaders.WithRange(0, 4)); err != nil || string(file.GetBody()) != "0123" {
+		t.Fatal("expected the cache to be bypassed", err)
+	}
+
+	m := fileloaders.New(httploader.With(http.DefaultClient))
+	requests = 0
+	var buf bytes.Buffer
+	n, err := fileloaders.Download(fileloaders.NewContext(ctx, m), ts.URL+"/flaky", &buf,
+		fileloaders.WithDownloadRetry(fileloaders.WithBackoff(time.Millisecond, time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(body)) || buf.String() != body || requests != 2 {
+		t.Fatal("invalid resumed download", n, requests)
+	}
+
+	// a file replaced between attempts is detected by its version when it has no ETag, as on GCS
+	store := &versionedStore{body: body}
+	m = fileloaders.New(func(m map[string]fileloaders.Loader) {
+		m["mem"] = store
+	})
+	buf.Reset()
+	n, err = fileloaders.Download(fileloaders.NewContext(ctx, m), "mem://b/file", &buf,
+		fileloaders.WithDownloadRetry(fileloaders.WithBackoff(time.Millisecond, time.Millisecond)))
+	if err == nil || !strings.Contains(err.Error(), "changed") || n != 300 || store.opens != 2 {
+		t.Fatal("expected the replaced file to fail the download", err, n, store.opens)
+	}
+}
+
+// versionedStore cuts off every body after 300 bytes and reports a new version on each Open.
+type versionedStore struct {
+	body  string
+	opens int
+}
+
+func (s *versionedStore) Open(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (io.ReadCloser, *fileloaders.File, error) {
+	s.opens++
+	version := strconv.Itoa(s.opens)
+	r := io.MultiReader(strings.NewReader(s.body[:300]), iotest.ErrReader(errors.New("connection reset")))
+	return io.NopCloser(r), fileloaders.Parse(path).Add(fileloaders.WithVersion(&version)), nil
+}
+
+func (s *versionedStore) Load(ctx context.Context, path string, opt ...fileloaders.LoaderOption) (*fileloaders.File, error) {
+	return nil, fileloaders.ErrNotSupported
+}
+
+func (s *versionedStore) List(ctx context.Context, path string, opt ...fileloaders.LoaderOption) ([]string, error) {
+	return nil, fileloaders.ErrNotSupported
+}
+
+func issueCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestHttpClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	ca, caKey, caPem, _ := issueCert(t, "ca", nil, nil)
+	_, _, serverPem, serverKeyPem := issueCert(t, "server", ca, caKey)
+	_, _, clientPem, clientKeyPem := issueCert(t, "client1", ca, caKey)
+	write := func(name string, body []byte) string {
+		path := filepath.Join(dir, name)
+		if _, err := fileloaders.SaveFile(ctx, path, bytes.NewReader(body)); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cfg := httploader.ClientConfig{
+		CertFile: write("client.pem", clientPem),
+		KeyFile:  write("client-key.pem", clientKeyPem),
+		CAFile:   write("ca.pem", caPem),
+		Timeout:  5 * time.Second,
+	}
+
+	serverCert, err := tls.X509KeyPair(serverPem, serverKeyPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	ts.StartTLS()
+	defer ts.Close()
+
+	client, err := httploader.NewClient(ctx, cfg, httploader.WithCertReload(fileloaders.WithInterval(20*time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := httploader.Load(ctx, client, ts.URL+"/whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "client1" {
+		t.Fatal("invalid client certificate", string(file.GetBody()))
+	}
+	noCert, err := httploader.NewClient(ctx, httploader.ClientConfig{CAFile: cfg.CAFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = httploader.Load(ctx, noCert, ts.URL+"/whoami"); err == nil {
+		t.Fatal("expected the handshake to fail without a client certificate")
+	}
+
+	_, _, clientPem, clientKeyPem = issueCert(t, "client2", ca, caKey)
+	write("client-key.pem", clientKeyPem)
+	write("client.pem", clientPem)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if file, err = httploader.Load(ctx, client, ts.URL+"/whoami"); err == nil && string(file.GetBody()) == "client2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("certificate was not reloaded", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "proxied "+r.URL.String())
+	}))
+	defer proxy.Close()
+	client, err = httploader.NewClient(ctx, httploader.ClientConfig{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, err = httploader.Load(ctx, client, "http://config.internal/app.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "proxied http://config.internal/app.yaml" {
+		t.Fatal("invalid proxy", string(file.GetBody()))
 	}
 }
 
@@ -204,11 +1777,11 @@ func TestS3(t *testing.T) {
 	if err := setupS3(ctx); err != nil {
 		t.Fatal(err)
 	}
-	list, err := fileloaders.List(ctx, "s3://test-bucket/README.md")
+	list, err := fileloaders.List(ctx, "s3://test-bucket")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(list) != 1 {
+	if !slices.Contains(list, "README.md") {
 		t.Fatal("invalid s3 list")
 	}
 
@@ -219,6 +1792,13 @@ func TestS3(t *testing.T) {
 	if string(file.GetBody()) != "# README" {
 		t.Fatal("invalid load")
 	}
+	file, err = fileloaders.Load(ctx, "s3://test-bucket/README.md", fileloaders.WithRange(2, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "REA" || file.Offset() != 2 {
+		t.Fatal("invalid range load", string(file.GetBody()))
+	}
 }
 
 func TestGs(t *testing.T) {
@@ -232,11 +1812,11 @@ func TestGs(t *testing.T) {
 			}
 		}()
 	}
-	list, err := fileloaders.List(ctx, "gs://test-bucket/README.md")
+	list, err := fileloaders.List(ctx, "gs://test-bucket")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(list) != 1 {
+	if !slices.Contains(list, "README.md") {
 		t.Fatal("invalid gs list")
 	}
 	file, err := fileloaders.Load(context.Background(), "gs://test-bucket/README.md")
@@ -246,6 +1826,13 @@ func TestGs(t *testing.T) {
 	if string(file.GetBody()) != "# README" {
 		t.Fatal("invalid load")
 	}
+	file, err = fileloaders.Load(ctx, "gs://test-bucket/README.md", fileloaders.WithRange(-6, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file.GetBody()) != "README" || file.Offset() != 2 {
+		t.Fatal("invalid range load", string(file.GetBody()))
+	}
 }
 
 func TestSsm(t *testing.T) {
@@ -275,11 +1862,26 @@ func TestSsm(t *testing.T) {
 	if string(file.GetBody()) != "secure-value/fileloaders/test" {
 		t.Fatal("invalid load")
 	}
+	files, err := fileloaders.LoadAll(ctx, []string{"ssm://parameter1/test", "ssm://parameter1/secure", "ssm://parameter1/missing"})
+	if !errors.Is(err, fileloaders.ErrNotExist) {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || string(files["ssm://parameter1/secure"].GetBody()) != "secure-value/fileloaders/test" {
+		t.Fatal("invalid load all")
+	}
+	if _, err = fileloaders.Save(ctx, "ssm://parameter1/test", strings.NewReader("updated")); err != nil {
+		t.Fatal(err)
+	}
+	if file, err = fileloaders.Load(ctx, "ssm://parameter1/test"); err != nil || string(file.GetBody()) != "updated" {
+		t.Fatal("expected the existing parameter to be replaced", err)
+	}
 }
 
 func TestGithub(t *testing.T) {
 	ctx := context.Background()
 	baseUrl := ""
+	contentsHits := 0
+	notModified := 0
 	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/repos/goccha/fileloaders/git/trees/main" {
 			_, _ = fmt.Fprint(w, `{
@@ -372,6 +1974,13 @@ func TestGithub(t *testing.T) {
 	"truncated": false
 }`)
 		} else if strings.HasPrefix(r.URL.Path, "/repos/goccha/fileloaders/contents") {
+			contentsHits++
+			if r.Header.Get("If-None-Match") == `"b6c811e"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"b6c811e"`)
 			body := `{
 	"name": "README.md",
 	"path": "README.md",
@@ -414,6 +2023,30 @@ func TestGithub(t *testing.T) {
 	if len(list) == 0 {
 		t.Fatal("invalid gs list")
 	}
+	entries, err := fileloaders.ListEntries(ctx, "github://goccha/fileloaders/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(list) || entries[0].Kind != fileloaders.KindDir || entries[1].Size != 5 {
+		t.Fatal("invalid github entries")
+	}
+	list, err = fileloaders.Glob(ctx, "github://goccha/fileloaders/*.go?ref=main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0] != "github://goccha/fileloaders/loders.go?ref=main" {
+		t.Fatal("invalid github glob", list)
+	}
+	count := 0
+	if err = fileloaders.Walk(ctx, "github://goccha/fileloaders/main", func(entry fileloaders.Entry) error {
+		count++
+		return fileloaders.SkipAll
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatal("invalid github walk")
+	}
 
 	file, err := fileloaders.Load(ctx, "github://goccha/fileloaders/README.md")
 	if err != nil {
@@ -422,4 +2055,25 @@ func TestGithub(t *testing.T) {
 	if string(file.GetBody()) != "# fileloaders" {
 		t.Fatal("invalid load")
 	}
+
+	cli := github.NewClient(nil)
+	cli.BaseURL = base
+	cache := fileloaders.NewCachingLoader(githubloader.New(cli), fileloaders.WithTTL(0))
+	contentsHits = 0
+	notModified = 0
+	for i := 0; i < 2; i++ {
+		file, err = cache.Load(ctx, "github://goccha/fileloaders/README.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(file.GetBody()) != "# fileloaders" {
+			t.Fatal("invalid cached load")
+		}
+	}
+	if contentsHits != 2 || notModified != 1 {
+		t.Fatal("expected revalidation with If-None-Match", contentsHits, notModified)
+	}
+	if _, err = githubloader.Load(ctx, cli, "github://goccha/fileloaders/README.md", fileloaders.IfChanged(file)); !errors.Is(err, fileloaders.ErrNotModified) {
+		t.Fatal("expected not modified", err)
+	}
 }
@@ -0,0 +1,10 @@
+module github.com/goccha/fileloaders/toml-decoder
+
+go 1.21
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/goccha/fileloaders v0.0.1-alpha.7
+)
+
+replace github.com/goccha/fileloaders => ../
@@ -0,0 +1,16 @@
+package tomldecoder
+
+import (
+	"github.com/BurntSushi/toml"
+	"github.com/goccha/fileloaders"
+)
+
+func Decode(data []byte, v any) error {
+	return toml.Unmarshal(data, v)
+}
+
+func Register() {
+	for _, key := range []string{".toml", "application/toml"} {
+		fileloaders.RegisterDecoder(key, Decode)
+	}
+}
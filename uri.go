@@ -0,0 +1,58 @@
+package fileloaders
+
+import (
+	"net/url"
+	"strings"
+)
+
+// URI is a parsed "scheme://bucket/key?query#fragment" path. Bucket is the host part
+// (a bucket, a GitHub owner or an HTTP host) and Key the decoded path without the leading slash.
+// Loaders read their per-call settings, such as "?version=" or "?ref=", from Query.
+type URI struct {
+	Scheme   string
+	User     *url.Userinfo
+	Bucket   string
+	Key      string
+	Query    url.Values
+	Fragment string
+}
+
+func ParseURI(s string) (*URI, error) {
+	if !strings.Contains(s, "://") {
+		return nil, ErrNotSupported
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &URI{
+		Scheme:   u.Scheme,
+		User:     u.User,
+		Bucket:   u.Host,
+		Key:      strings.TrimPrefix(u.Path, "/"),
+		Query:    u.Query(),
+		Fragment: u.Fragment,
+	}, nil
+}
+
+func (u *URI) String() string {
+	v := url.URL{
+		Scheme:   u.Scheme,
+		User:     u.User,
+		Host:     u.Bucket,
+		Path:     "/" + u.Key,
+		RawQuery: u.Query.Encode(),
+		Fragment: u.Fragment,
+	}
+	return v.String()
+}
+
+// Get returns the query parameter name, or "" when it is not set.
+func (u *URI) Get(name string) string {
+	return u.Query.Get(name)
+}
+
+// Has reports whether the query parameter name is set.
+func (u *URI) Has(name string) bool {
+	return u.Query.Has(name)
+}
@@ -0,0 +1,280 @@
+package fileloaders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EventKind int
+
+const (
+	EventChanged EventKind = iota
+	EventRemoved
+	EventError
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventRemoved:
+		return "removed"
+	case EventError:
+		return "error"
+	default:
+		return "changed"
+	}
+}
+
+// Event is sent by Watch. File holds the new content for EventChanged and Err the failure for EventError.
+type Event struct {
+	Kind EventKind
+	Path string
+	File *File
+	Err  error
+}
+
+type WatchOption func(o *WatchOptions)
+
+// WatchOptions holds the WatchOption settings. Watchers read them with NewWatchOptions.
+type WatchOptions struct {
+	Interval      time.Duration
+	Jitter        time.Duration
+	Debounce      time.Duration
+	LoaderOptions []LoaderOption
+}
+
+func NewWatchOptions(opt ...WatchOption) *WatchOptions {
+	o := &WatchOptions{
+		Interval: 30 * time.Second,
+	}
+	for _, v := range opt {
+		v(o)
+	}
+	return o
+}
+
+// WithInterval sets how often files are polled.
+func WithInterval(interval time.Duration) WatchOption {
+	return func(o *WatchOptions) {
+		o.Interval = interval
+	}
+}
+
+// WithJitter adds a random delay up to jitter to every poll, so that many processes do not poll at once.
+func WithJitter(jitter time.Duration) WatchOption {
+	return func(o *WatchOptions) {
+		o.Jitter = jitter
+	}
+}
+
+// WithDebounce waits until a change has settled for the duration before reporting it.
+func WithDebounce(debounce time.Duration) WatchOption {
+	return func(o *WatchOptions) {
+		o.Debounce = debounce
+	}
+}
+
+// WithWatchLoaderOptions passes LoaderOptions to the Stat and Load calls made while watching.
+func WithWatchLoaderOptions(opt ...LoaderOption) WatchOption {
+	return func(o *WatchOptions) {
+		o.LoaderOptions = append(o.LoaderOptions, opt...)
+	}
+}
+
+// Watcher is implemented by loaders that are notified of changes by their backend.
+// Loaders that do not implement it are polled through Stat, or Load when Stat is not supported.
+type Watcher interface {
+	Watch(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error)
+}
+
+func Watch(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error) {
+	if m := FromContext(ctx); m != nil {
+		if v, err := m.Watch(ctx, path, opt...); err != nil {
+			if !errors.Is(err, ErrNotSupported) {
+				return nil, err
+			}
+		} else {
+			return v, nil
+		}
+	}
+	return WatchFile(ctx, path, opt...)
+}
+
+func (m *MapLoader) Watch(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error) {
+	loader, prefix, ok := m.lookup(path)
+	if !ok {
+		if prefix == "file" {
+			return WatchFile(ctx, path, opt...)
+		}
+		return nil, ErrNotSupported
+	}
+	return watch(ctx, loader, path, opt...)
+}
+
+func watch(ctx context.Context, loader Loader, path string, opt ...WatchOption) (<-chan Event, error) {
+	if v, ok := loader.(Watcher); ok {
+		return v.Watch(ctx, path, opt...)
+	}
+	return Poll(ctx, loader, path, opt...)
+}
+
+// Poll watches path by comparing the hash, version and modification time reported by Stat on every interval.
+// Files whose metadata carries none of them are downloaded and compared by content.
+func Poll(ctx context.Context, loader Loader, path string, opt ...WatchOption) (<-chan Event, error) {
+	o := NewWatchOptions(opt...)
+	if o.Interval <= 0 {
+		return nil, errors.New("fileloaders: watch interval must be positive")
+	}
+	check := func() (string, *File, error) {
+		return fingerprint(ctx, loader, path, o.LoaderOptions...)
+	}
+	last, _, err := check()
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	ch := make(chan Event, 1)
+	go func() {
+		defer close(ch)
+		for {
+			if !sleep(ctx, o.Interval+jitter(o.Jitter)) {
+				return
+			}
+			current, file, err := check()
+			if err == nil && current != last && o.Debounce > 0 {
+				for err == nil {
+					if !sleep(ctx, o.Debounce) {
+						return
+					}
+					var settled string
+					if settled, file, err = check(); err == nil && settled == current {
+						break
+					}
+					current = settled
+				}
+			}
+			var event *Event
+			last, event = compare(ctx, loader, path, last, current, file, err, o.LoaderOptions...)
+			if event != nil && !send(ctx, ch, *event) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// compare returns the new fingerprint and the event to report for a check result.
+func compare(ctx context.Context, loader Loader, path, last, current string, file *File, err error, opt ...LoaderOption) (string, *Event) {
+	switch {
+	case errors.Is(err, ErrNotExist):
+		if last == "" {
+			return last, nil
+		}
+		return "", &Event{Kind: EventRemoved, Path: path}
+	case err != nil:
+		return last, &Event{Kind: EventError, Path: path, Err: err}
+	case current == last:
+		return last, nil
+	}
+	if file == nil {
+		if file, err = loader.Load(ctx, path, opt...); err != nil {
+			return last, &Event{Kind: EventError, Path: path, Err: err}
+		}
+	}
+	return current, &Event{Kind: EventChanged, Path: path, File: file}
+}
+
+// fingerprint identifies the current state of a file. The file is only returned when it had to be loaded.
+func fingerprint(ctx context.Context, loader Loader, path string, opt ...LoaderOption) (string, *File, error) {
+	info, err := stat(ctx, loader, path, opt...)
+	if err == nil && (info.Hash != "" || info.Version != "" || !info.ModTime.IsZero()) {
+		return strings.Join([]string{info.Hash, info.Version, strconv.FormatInt(info.ModTime.UnixNano(), 10), strconv.FormatInt(info.Size, 10)}, "|"), nil, nil
+	}
+	if err != nil && !errors.Is(err, ErrNotSupported) {
+		return "", nil, err
+	}
+	file, err := loader.Load(ctx, path, opt...)
+	if err != nil {
+		return "", nil, err
+	}
+	return contentHash(file), file, nil
+}
+
+func contentHash(file *File) string {
+	sum := sha256.Sum256(file.body)
+	return hex.EncodeToString(sum[:])
+}
+
+// WatchFunc watches a single path, as WatchFile does.
+type WatchFunc func(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error)
+
+var (
+	fileWatcherMu sync.RWMutex
+	fileWatcher   WatchFunc
+)
+
+// RegisterFileWatcher makes WatchFile use fn instead of polling, such as the watcher of the fsnotify-watcher module.
+func RegisterFileWatcher(fn WatchFunc) {
+	fileWatcherMu.Lock()
+	defer fileWatcherMu.Unlock()
+	fileWatcher = fn
+}
+
+// WatchFile watches a local file with the watcher given to RegisterFileWatcher,
+// or by polling its modification time and size on every interval.
+func WatchFile(ctx context.Context, path string, opt ...WatchOption) (<-chan Event, error) {
+	fileWatcherMu.RLock()
+	fn := fileWatcher
+	fileWatcherMu.RUnlock()
+	if fn != nil {
+		return fn(ctx, path, opt...)
+	}
+	return Poll(ctx, localLoader{}, path, opt...)
+}
+
+// localLoader exposes the local file functions as a Loader for Poll.
+type localLoader struct{}
+
+func (localLoader) Load(ctx context.Context, path string, opt ...LoaderOption) (*File, error) {
+	return LoadFile(ctx, path, opt...)
+}
+
+func (localLoader) List(ctx context.Context, path string, opt ...LoaderOption) ([]string, error) {
+	return ListFile(ctx, path, opt...)
+}
+
+func (localLoader) Stat(ctx context.Context, path string, opt ...LoaderOption) (*FileInfo, error) {
+	return StatFile(ctx, path)
+}
+
+func send(ctx context.Context, ch chan<- Event, event Event) bool {
+	select {
+	case ch <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,10 @@
+module github.com/goccha/fileloaders/yaml-decoder
+
+go 1.21
+
+require (
+	github.com/goccha/fileloaders v0.0.1-alpha.7
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/goccha/fileloaders => ../
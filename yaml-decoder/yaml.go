@@ -0,0 +1,16 @@
+package yamldecoder
+
+import (
+	"github.com/goccha/fileloaders"
+	"gopkg.in/yaml.v3"
+)
+
+func Decode(data []byte, v any) error {
+	return yaml.Unmarshal(data, v)
+}
+
+func Register() {
+	for _, key := range []string{".yaml", ".yml", "application/yaml", "application/x-yaml", "text/yaml"} {
+		fileloaders.RegisterDecoder(key, Decode)
+	}
+}